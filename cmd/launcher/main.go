@@ -8,6 +8,7 @@ import (
 
 	"tooie-shelf/internal/app"
 	"tooie-shelf/internal/config"
+	"tooie-shelf/internal/sys"
 )
 
 func main() {
@@ -23,14 +24,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Probe terminal capabilities before Bubble Tea takes over stdin
+	caps := sys.ProbeTerminal(sys.DefaultProbeTimeout)
+
 	// Create model
-	model := app.NewModel(cfg)
+	model := app.NewModel(cfg, caps)
 
 	// Create program with mouse support
 	p := tea.NewProgram(
 		model,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
+		tea.WithFilter(app.Filter),
 	)
 
 	// Run
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"tooie-shelf/internal/graphics"
+	"tooie-shelf/internal/sys"
+)
+
+// iconAnimation is the playback state of an animated icon, with every frame
+// pre-encoded for the icon's current cell size.
+type iconAnimation struct {
+	Frames []graphics.Payload
+	Delays []time.Duration
+	Frame  int       // Frame currently on screen
+	Next   time.Time // When the next frame is due
+}
+
+// animationJob describes one animated icon to encode.
+type animationJob struct {
+	Index  int
+	Icon   *graphics.AnimatedIcon
+	Width  int // Cells
+	Height int // Cells
+	Style  graphics.IconStyle
+}
+
+// animationsEncodedMsg carries pre-encoded animations by display index.
+// Generation identifies the geometry they were encoded for.
+type animationsEncodedMsg struct {
+	Generation int
+	Animations map[int]*iconAnimation
+}
+
+// animationTickMsg advances every animation whose next frame is due.
+type animationTickMsg struct {
+	Generation int
+}
+
+// startAnimations pre-encodes the animated icons on the current page for the current geometry.
+// Any running playback is stopped: its ticks carry an outdated generation.
+func (m *Model) startAnimations() tea.Cmd {
+	m.AnimationGen++
+	m.Animations = nil
+	if !m.Config.Behavior.AnimateIcons || !m.Ready || m.Folder != nil {
+		return nil
+	}
+
+	var jobs []animationJob
+	for i, icon := range m.Icons {
+		anim, ok := icon.(*graphics.AnimatedIcon)
+		if !ok || !m.onPage(i) {
+			continue
+		}
+		w, h, _ := m.scaledIconCells(i)
+		jobs = append(jobs, animationJob{Index: i, Icon: anim, Width: w, Height: h, Style: m.payloadStyle(i, w, h)})
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+	return encodeAnimations(jobs, m.Renderer, m.CellPx, m.AnimationGen)
+}
+
+// encodeAnimations renders every frame of the given animations in the background.
+// Frames skip the on-disk payload cache, which would fill with every frame of every size.
+func encodeAnimations(jobs []animationJob, r graphics.Renderer, cellPx sys.CellDim, gen int) tea.Cmd {
+	return func() tea.Msg {
+		anims := make(map[int]*iconAnimation, len(jobs))
+		for _, job := range jobs {
+			frames := make([]graphics.Payload, len(job.Icon.Frames))
+			for i, frame := range job.Icon.Frames {
+				frames[i] = r.Render(frame, job.Width, job.Height, cellPx, job.Style)
+			}
+			anims[job.Index] = &iconAnimation{Frames: frames, Delays: job.Icon.Delays}
+		}
+		return animationsEncodedMsg{Generation: gen, Animations: anims}
+	}
+}
+
+// scheduleAnimationTick ticks when the earliest animation frame is due.
+func (m *Model) scheduleAnimationTick() tea.Cmd {
+	var next time.Time
+	for _, a := range m.Animations {
+		if next.IsZero() || a.Next.Before(next) {
+			next = a.Next
+		}
+	}
+	if next.IsZero() {
+		return nil
+	}
+	gen := m.AnimationGen
+	return tea.Tick(time.Until(next), func(time.Time) tea.Msg { return animationTickMsg{Generation: gen} })
+}
+
+// playAnimations starts playback of freshly encoded animations at their first frame.
+func (m *Model) playAnimations(anims map[int]*iconAnimation) tea.Cmd {
+	now := time.Now()
+	for _, a := range anims {
+		a.Next = now.Add(a.Delays[0])
+	}
+	m.Animations = anims
+	return m.scheduleAnimationTick()
+}
+
+// advanceAnimations draws the next frame of every due animation via direct ANSI,
+// so only the animated cells are redrawn.
+func (m *Model) advanceAnimations() tea.Cmd {
+	now := time.Now()
+	var b strings.Builder
+	for index, a := range m.Animations {
+		if now.Before(a.Next) {
+			continue
+		}
+		prev := a.Frames[a.Frame]
+		a.Frame = (a.Frame + 1) % len(a.Frames)
+		drop := m.bounceRow(index)
+		b.WriteString(m.iconSwapOutput(index, prev, drop, a.Frames[a.Frame], drop))
+
+		a.Next = a.Next.Add(a.Delays[a.Frame])
+		if a.Next.Before(now) {
+			// Fell behind (e.g. the device slept): resync instead of catching up
+			a.Next = now.Add(a.Delays[a.Frame])
+		}
+	}
+	if b.Len() > 0 {
+		fmt.Fprint(os.Stdout, syncStart+b.String()+fmt.Sprintf(cursorTo, m.TermHeight, 1)+syncEnd)
+	}
+	return m.scheduleAnimationTick()
+}
+
+// currentPayload returns the payload on screen for an app's icon: the current
+// animation frame, or the still icon.
+func (m *Model) currentPayload(index int) graphics.Payload {
+	if a, ok := m.Animations[index]; ok {
+		return a.Frames[a.Frame]
+	}
+	w, h, scale := m.scaledIconCells(index)
+	return m.getSixelContentWithDimensions(index, w, h, scale)
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"tooie-shelf/internal/sys"
+)
+
+// badgeMaxWidth is the widest badge drawn (" 99+ "), cleared when a badge shrinks.
+const badgeMaxWidth = 5
+
+// badgeMaxBackoff is the longest delay between polls while they keep failing.
+const badgeMaxBackoff = 5 * time.Minute
+
+// badgeRedrawDelay lets the renderer draw the grid before badges are drawn over it.
+const badgeRedrawDelay = 200 * time.Millisecond
+
+// badgesMsg carries notification counts per package.
+type badgesMsg struct {
+	Counts     map[string]int
+	Err        error
+	Generation int // BadgeGen of the poll loop that fetched them
+}
+
+// badgesRedrawMsg requests drawing every badge after the grid was redrawn.
+type badgesRedrawMsg struct{}
+
+// redrawBadges draws all badges once the renderer has drawn the grid.
+func redrawBadges() tea.Cmd {
+	return tea.Tick(badgeRedrawDelay, func(time.Time) tea.Msg { return badgesRedrawMsg{} })
+}
+
+// pollBadges fetches notification counts after delay for the poll loop of generation gen.
+func pollBadges(gen int, delay time.Duration) tea.Cmd {
+	fetch := func(time.Time) tea.Msg {
+		counts, err := sys.NotificationCounts()
+		return badgesMsg{Counts: counts, Err: err, Generation: gen}
+	}
+	if delay <= 0 {
+		return func() tea.Msg { return fetch(time.Now()) }
+	}
+	return tea.Tick(delay, fetch)
+}
+
+// badgesFailed schedules the next poll after a failed one, doubling the delay on each
+// failure up to badgeMaxBackoff. Polling only stops when termux-notification-list is
+// not installed; other failures, such as a timeout, may pass.
+func (m *Model) badgesFailed(err error) tea.Cmd {
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil
+	}
+	interval := m.Config.GetBadgeInterval()
+	m.BadgeBackoff = max(min(max(2*m.BadgeBackoff, 2*interval), badgeMaxBackoff), interval)
+	return pollBadges(m.BadgeGen, m.BadgeBackoff)
+}
+
+// updateBadges stores new notification counts and redraws only the badges that changed.
+// Badges are drawn with direct ANSI (like flashCell), so icons are never redrawn for them.
+func (m *Model) updateBadges(counts map[string]int) {
+	old := m.Badges
+	// Replace rather than mutate: border redraw goroutines may read the old map
+	m.Badges = counts
+
+	if !m.Ready {
+		return
+	}
+	if m.Folder != nil {
+		// The grid is covered; only the folder's badges are on screen
+		m.Folder.updateBadges(counts)
+		return
+	}
+
+	var output string
+	for i, app := range m.DisplayApps {
+		if app.Package == "" || old[app.Package] == counts[app.Package] || !m.onPage(i) {
+			continue
+		}
+		output += m.clearBadge(i) + m.badgeOutput(i)
+	}
+	if output != "" {
+		output += fmt.Sprintf("\x1b[%d;1H", m.TermHeight)
+		fmt.Fprint(os.Stdout, output)
+	}
+}
+
+// badgeText returns the badge label for a notification count.
+func (m *Model) badgeText(count int) string {
+	if count <= 0 {
+		return ""
+	}
+	if m.Config.GetBadges() == "dot" {
+		return " ● "
+	}
+	if count > 99 {
+		return " 99+ "
+	}
+	return fmt.Sprintf(" %d ", count)
+}
+
+// badgeOrigin returns the 1-indexed position of a badge of the given width:
+// right-aligned on the cell's top row, inside the border corner.
+func (m *Model) badgeOrigin(index, width int) (x, y int) {
+	r := m.cellRect(index)
+
+	x = r.Max.X - width + 1
+	left := r.Min.X + 1
+	if m.Config.Style.Border {
+		x-- // Keep the rounded corners
+		left++
+	}
+	// Narrow cells must not reach into the left neighbor
+	return max(x, left), r.Min.Y + 1
+}
+
+// badgeOutput returns the ANSI sequence drawing the badge for the app at index, if any.
+func (m *Model) badgeOutput(index int) string {
+	if m.Config.GetBadges() == "none" || !m.onPage(index) {
+		return ""
+	}
+	text := m.badgeText(m.Badges[m.DisplayApps[index].Package])
+	if text == "" {
+		return ""
+	}
+
+	width := len([]rune(text))
+	x, y := m.badgeOrigin(index, width)
+	return fmt.Sprintf("\x1b[%d;%dH\x1b[97;48;5;%sm%s\x1b[0m", y, x, m.Config.GetBadgeColor(), text)
+}
+
+// clearBadge returns the ANSI sequence erasing any badge on the app's cell by redrawing
+// what lies under it: the wallpaper or blank cells, the top border segment, and the icon
+// when the badge sits on its top row.
+func (m *Model) clearBadge(index int) string {
+	x, y := m.badgeOrigin(index, badgeMaxWidth)
+	right := m.cellRect(index).Max.X
+	if m.Config.Style.Border {
+		right--
+	}
+	area := image.Rect(x-1, y-1, right, y) // 0-based cells
+	if area.Empty() {
+		return ""
+	}
+
+	var b strings.Builder
+	switch {
+	case m.Wallpaper != nil:
+		b.WriteString(m.wallpaperOutput(area))
+	case !m.Config.Style.Border:
+		fmt.Fprintf(&b, cursorTo+"\x1b[%dX", y, x, area.Dx())
+	}
+	if m.Config.Style.Border {
+		color := fmt.Sprintf("\x1b[38;5;%sm", m.Config.GetBorderColor())
+		fmt.Fprintf(&b, cursorTo+"%s%s\x1b[0m", y, x, color, strings.Repeat("─", area.Dx()))
+	}
+
+	if p := m.currentPayload(index); p.Data != "" {
+		px, py := m.iconOrigin(index, p.Width, p.Height)
+		drop := m.bounceRow(index)
+		if cellBounds(p, m.CellPx).Add(image.Pt(px-1, py-1+drop)).Overlaps(area) {
+			b.WriteString(m.iconSwapOutput(index, p, drop, p, drop))
+		}
+	}
+	return b.String()
+}
+
+// drawAllBadges draws every badge via direct ANSI.
+func (m *Model) drawAllBadges() {
+	if !m.Ready {
+		return
+	}
+	if m.Folder != nil {
+		m.Folder.drawAllBadges()
+		return
+	}
+	var output string
+	start, end := m.pageApps()
+	for i := start; i < end; i++ {
+		output += m.badgeOutput(i)
+	}
+	if output != "" {
+		output += fmt.Sprintf("\x1b[%d;1H", m.TermHeight)
+		fmt.Fprint(os.Stdout, output)
+	}
+}
@@ -0,0 +1,175 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/harmonica"
+)
+
+// Tap bounce physics. The spring pulls the icon back to its resting row;
+// a tap kicks it downward, and the underdamped spring dips about one row and settles.
+const (
+	bounceFPS       = 60
+	bounceFrequency = 10.0 // Angular frequency (rad/s)
+	bounceDamping   = 0.5  // Damping ratio; below 1 overshoots a little
+	bounceVelocity  = 18.0 // Initial downward velocity in rows/s (peak dip ≈ 1 row)
+	bounceMaxDrop   = 1    // Deepest row offset drawn
+	bounceRestDelta = 0.01 // Position and velocity below which a spring counts as settled
+)
+
+// bounce is the spring state of one tapped icon.
+type bounce struct {
+	Spring   harmonica.Spring
+	Pos      float64 // Y offset in rows
+	Velocity float64 // Rows per second
+	Row      int     // Offset currently drawn on screen
+	Settled  bool    // Back at rest, waiting for the other springs to settle
+}
+
+// bounceTickMsg steps every active spring by one frame.
+// Generation is the BounceGen of the tick loop that sent it.
+type bounceTickMsg struct {
+	Generation int
+}
+
+// bounceTick schedules the next physics step of the tick loop of generation gen.
+func bounceTick(gen int) tea.Cmd {
+	return tea.Tick(time.Second/bounceFPS, func(time.Time) tea.Msg { return bounceTickMsg{Generation: gen} })
+}
+
+// startBounce kicks the tapped icon downward. A tick loop is only started when
+// no other spring is running, since that loop already steps every spring.
+func (m *Model) startBounce(index int) tea.Cmd {
+	if !m.Config.Behavior.Animation || index >= len(m.Icons) || m.Icons[index] == nil {
+		return nil
+	}
+	running := len(m.Bounces) > 0
+
+	b, ok := m.Bounces[index]
+	if !ok {
+		b = &bounce{Spring: harmonica.NewSpring(harmonica.FPS(bounceFPS), bounceFrequency, bounceDamping)}
+		m.Bounces[index] = b
+	}
+	b.Velocity = bounceVelocity
+	b.Settled = false
+
+	if running {
+		return nil
+	}
+	return bounceTick(m.BounceGen)
+}
+
+// stepBounces advances every spring and moves icons whose drawn row changed.
+// Ticking stops once every spring has settled.
+func (m *Model) stepBounces() tea.Cmd {
+	var out strings.Builder
+	settled := true
+	for index, b := range m.Bounces {
+		if b.Settled {
+			continue
+		}
+		b.Pos, b.Velocity = b.Spring.Update(b.Pos, b.Velocity, 0)
+
+		row := max(0, min(bounceMaxDrop, int(math.Round(b.Pos))))
+		if math.Abs(b.Pos) < bounceRestDelta && math.Abs(b.Velocity) < bounceRestDelta {
+			row = 0
+			b.Settled = true
+		} else {
+			settled = false
+		}
+		// Only redraw when the icon actually moves to another row
+		if row != b.Row {
+			p := m.currentPayload(index)
+			out.WriteString(m.iconSwapOutput(index, p, b.Row, p, row))
+			b.Row = row
+		}
+	}
+
+	if settled {
+		// Moving icons may have covered the border or, over a wallpaper, erased part of it
+		out.WriteString(m.restoreAfterBounce())
+		clear(m.Bounces)
+	}
+	if out.Len() > 0 {
+		fmt.Fprint(os.Stdout, syncStart+out.String()+fmt.Sprintf(cursorTo, m.TermHeight, 1)+syncEnd)
+	}
+
+	if settled {
+		return nil
+	}
+	return bounceTick(m.BounceGen)
+}
+
+// bounceRow returns the row offset an icon is currently drawn at.
+func (m *Model) bounceRow(index int) int {
+	if b, ok := m.Bounces[index]; ok {
+		return b.Row
+	}
+	return 0
+}
+
+// restoreAfterBounce returns the ANSI sequence repairing what the bounced icons drew over.
+// Over a wallpaper, the wallpaper under each bounced icon is redrawn along with the icons,
+// frames and badges on it; otherwise only borders and labels are.
+func (m *Model) restoreAfterBounce() string {
+	var b strings.Builder
+	start, end := m.pageApps()
+	if m.Wallpaper != nil {
+		var areas []image.Rectangle
+		for index := range m.Bounces {
+			// A dipping icon may reach below its cells
+			area := m.cellRect(index)
+			area.Max.Y += bounceMaxDrop
+			areas = append(areas, area)
+			b.WriteString(m.wallpaperOutput(area))
+		}
+		covered := func(r image.Rectangle) bool {
+			for _, area := range areas {
+				if r.Overlaps(area) {
+					return true
+				}
+			}
+			return false
+		}
+
+		for i := start; i < end; i++ {
+			if covered(m.cellRect(i)) {
+				if p := m.currentPayload(i); p.Data != "" {
+					x, y := m.iconOrigin(i, p.Width, p.Height)
+					fmt.Fprintf(&b, cursorTo, y, x)
+					b.WriteString(p.Data)
+				}
+			}
+		}
+		color := m.Config.GetBorderColor()
+		for i := start; i < end; i++ {
+			if covered(m.cellRect(i)) {
+				b.WriteString(m.borderOutput(i, color))
+				b.WriteString(m.labelOutput(i))
+				b.WriteString(m.badgeOutput(i))
+			}
+		}
+		if m.Config.Style.Border {
+			for _, r := range m.emptyCells() {
+				if covered(r) {
+					b.WriteString(boxOutput(r, color))
+				}
+			}
+		}
+		return b.String()
+	}
+
+	color := m.Config.GetBorderColor()
+	for i := start; i < end; i++ {
+		b.WriteString(m.borderOutput(i, color))
+		b.WriteString(m.labelOutput(i))
+		b.WriteString(m.badgeOutput(i))
+	}
+	return b.String()
+}
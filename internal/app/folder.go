@@ -0,0 +1,155 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"tooie-shelf/internal/config"
+	"tooie-shelf/internal/graphics"
+)
+
+// folderIcons holds the loaded icons of a folder's apps.
+type folderIcons struct {
+	Icons   []image.Image
+	Sources []string
+}
+
+// loadFolder loads a folder's apps in parallel and composes their 2x2 mosaic as the
+// folder's icon. A folder with its own icon configured uses that instead.
+func loadFolder(app config.AppConfig, cfg config.Config, timeout time.Duration) (image.Image, *folderIcons) {
+	children := &folderIcons{
+		Icons:   make([]image.Image, len(app.Folder)),
+		Sources: make([]string, len(app.Folder)),
+	}
+
+	var wg sync.WaitGroup
+	for i, child := range app.Folder {
+		wg.Add(1)
+		go func(i int, child config.AppConfig) {
+			defer wg.Done()
+			children.Icons[i] = loadAppIcon(child, cfg, timeout)
+			children.Sources[i], _ = iconSource(child, cfg)
+		}(i, child)
+	}
+	wg.Wait()
+
+	if app.Icon != "" {
+		return loadAppIcon(app, cfg, timeout), children
+	}
+	return graphics.NewFolderIcon(children.Icons), children
+}
+
+// folderSource identifies a folder's icon like iconSource: its own icon's source when
+// one is configured, or else the sources of the apps its mosaic is composed of.
+func folderSource(app config.AppConfig, cfg config.Config, children *folderIcons) string {
+	if app.Icon != "" {
+		source, _ := iconSource(app, cfg)
+		return source
+	}
+	return "folder:" + strings.Join(children.Sources, ",")
+}
+
+// openFolder shows the apps of the folder at a display index in a panel over the grid.
+func (m *Model) openFolder(index int) tea.Cmd {
+	m.Folder = m.newFolder(index)
+	m.FolderIndex = index
+	return m.redrawGrid()
+}
+
+// closeFolder hides the open folder and redraws the grid under it.
+func (m *Model) closeFolder() tea.Cmd {
+	m.Folder = nil
+	return m.redrawGrid()
+}
+
+// newFolder returns the model of a folder's panel: a grid of its own over the folder's
+// apps, laid out in folderArea with automatic rows and columns. It shares the
+// renderer, geometry and badges of m.
+func (m *Model) newFolder(index int) *Model {
+	apps := m.DisplayApps[index].Folder
+	n := len(apps)
+
+	f := &Model{
+		Config:      m.Config,
+		DisplayApps: apps,
+		TermWidth:   m.TermWidth,
+		TermHeight:  m.TermHeight,
+		CellPx:      m.CellPx,
+		Caps:        m.Caps,
+		Icons:       make([]image.Image, n),
+		IconSources: make([]string, n),
+		Renderer:    m.Renderer,
+		Bounces:     make(map[int]*bounce),
+		Badges:      m.Badges,
+		ErrorFlash:  make([]bool, n),
+		Selected:    -1,
+		Ready:       m.Ready,
+	}
+	if children, ok := m.FolderIcons[index]; ok {
+		copy(f.Icons, children.Icons)
+		copy(f.IconSources, children.Sources)
+	}
+	f.Config.Grid = config.GridConfig{Rows: config.GridAuto, Columns: config.GridAuto}
+	f.Area = m.folderArea(n)
+	f.updateGrid()
+	f.ClearCache()
+	return f
+}
+
+// folderArea returns the cells of a folder's grid holding n apps: a roughly square
+// block of cells the size of the main grid's, centered over the grid and leaving
+// room for the panel's frame.
+func (m *Model) folderArea(n int) image.Rectangle {
+	cols := max(int(math.Ceil(math.Sqrt(float64(n)))), 1)
+	rows := max((n+cols-1)/cols, 1)
+	cellW, cellH := m.GridCellSize()
+	grid := m.gridArea()
+
+	w := max(min(cols*cellW, grid.Dx()-2), 1)
+	h := max(min(rows*cellH, grid.Dy()-2), 1)
+	x := grid.Min.X + (grid.Dx()-w)/2
+	y := grid.Min.Y + (grid.Dy()-h)/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// folderTap handles a tap while a folder is open: its apps launch, its frame and
+// padding ignore taps, and a tap outside the panel closes the folder.
+func (m *Model) folderTap(x, y int) tea.Cmd {
+	f := m.Folder
+	if index := f.HitTest(x, y); index >= 0 {
+		f.flashCell(index)
+		return m.launch(f.DisplayApps[index])
+	}
+	if image.Pt(x, y).In(f.Area.Inset(-1)) {
+		return nil
+	}
+	return m.closeFolder()
+}
+
+// folderOutput returns the ANSI sequence drawing an open folder over the grid:
+// a blanked, framed panel holding the folder's apps with their borders, labels and badges.
+func (f *Model) folderOutput() string {
+	var b strings.Builder
+	panel := f.Area.Inset(-1)
+	for y := panel.Min.Y; y < panel.Max.Y; y++ {
+		fmt.Fprintf(&b, cursorTo+"\x1b[%dX", y+1, panel.Min.X+1, panel.Dx())
+	}
+	b.WriteString(boxOutput(panel, f.Config.GetHighlightColor()))
+
+	color := f.Config.GetBorderColor()
+	for i := range f.DisplayApps {
+		b.WriteString(f.borderOutput(i, color))
+		b.WriteString(f.labelOutput(i))
+	}
+	f.drawSixelsDirectly(&b)
+	for i := range f.DisplayApps {
+		b.WriteString(f.badgeOutput(i))
+	}
+	return b.String()
+}
@@ -0,0 +1,114 @@
+package app
+
+import "image"
+
+// span returns the grid rows and columns the app at a display index covers,
+// clamped to a rows x cols grid.
+func (m *Model) span(index, rows, cols int) (spanRows, spanCols int) {
+	spanRows, spanCols = m.DisplayApps[index].GetSpan()
+	return min(spanRows, rows), min(spanCols, cols)
+}
+
+// pack lays out the apps of the display section [start, end) on pages of a rows x cols
+// grid, storing each app's grid cells in layout. Every app takes the first free block
+// of its span, scanning row by row, so smaller apps fill the holes larger ones leave;
+// an app fitting nowhere starts the next page.
+func (m *Model) pack(start, end, rows, cols int, layout []image.Rectangle) []pageRange {
+	var pages []pageRange
+	used := make([]bool, rows*cols)
+	page := pageRange{Start: start, End: start}
+	for i := start; i < end; i++ {
+		spanRows, spanCols := m.span(i, rows, cols)
+		r, ok := place(used, rows, cols, spanRows, spanCols)
+		if !ok {
+			// Spans are clamped to the grid, so an empty page always fits
+			pages = append(pages, page)
+			page = pageRange{Start: i, End: i}
+			clear(used)
+			r, _ = place(used, rows, cols, spanRows, spanCols)
+		}
+		layout[i] = r
+		page.End = i + 1
+	}
+	if page.End > page.Start {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// place finds the first free spanRows x spanCols block of a rows x cols grid, row by row,
+// and marks it used. used holds one flag per grid cell in row-major order.
+func place(used []bool, rows, cols, spanRows, spanCols int) (image.Rectangle, bool) {
+	for y := 0; y+spanRows <= rows; y++ {
+		for x := 0; x+spanCols <= cols; x++ {
+			r := image.Rect(x, y, x+spanCols, y+spanRows)
+			if blockFree(used, cols, r) {
+				markUsed(used, cols, r)
+				return r, true
+			}
+		}
+	}
+	return image.Rectangle{}, false
+}
+
+// blockFree reports whether no grid cell of r is used.
+func blockFree(used []bool, cols int, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if used[y*cols+x] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// markUsed marks every grid cell of r used.
+func markUsed(used []bool, cols int, r image.Rectangle) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			used[y*cols+x] = true
+		}
+	}
+}
+
+// fits reports whether every display section fits on a single page of a rows x cols grid.
+func (m *Model) fits(rows, cols int) bool {
+	layout := make([]image.Rectangle, len(m.DisplayApps))
+	bounds := m.sectionBounds()
+	for i := 0; i+1 < len(bounds); i++ {
+		if len(m.pack(bounds[i], bounds[i+1], rows, cols, layout)) > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// gridRect converts grid cells to the terminal cells (0-based) they cover.
+func (m *Model) gridRect(r image.Rectangle) image.Rectangle {
+	cellW, cellH := m.GridCellSize()
+	area := m.gridArea()
+	return image.Rect(
+		area.Min.X+r.Min.X*cellW, area.Min.Y+r.Min.Y*cellH,
+		area.Min.X+r.Max.X*cellW, area.Min.Y+r.Max.Y*cellH,
+	)
+}
+
+// emptyCells returns the terminal cells (0-based) of every grid cell no app on the
+// current page covers.
+func (m *Model) emptyCells() []image.Rectangle {
+	used := make([]bool, m.Rows*m.Columns)
+	start, end := m.pageApps()
+	for i := start; i < end; i++ {
+		markUsed(used, m.Columns, m.Layout[i])
+	}
+
+	var cells []image.Rectangle
+	for i, u := range used {
+		if !u {
+			x, y := i%m.Columns, i/m.Columns
+			cells = append(cells, m.gridRect(image.Rect(x, y, x+1, y+1)))
+		}
+	}
+	return cells
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// spinnerFrames are drawn in the center of each icon area until its icon arrives.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is the time between spinner frames.
+const spinnerInterval = 100 * time.Millisecond
+
+// iconLoadedMsg carries one loaded icon and its source identity (see iconSource).
+// Folders also carry the icons of their apps. Generation is the IconGen it was loaded for.
+type iconLoadedMsg struct {
+	Generation int
+	Index      int
+	Icon       image.Image
+	Source     string
+	Children   *folderIcons
+}
+
+// spinnerTickMsg advances the loading spinners.
+type spinnerTickMsg struct{}
+
+// loadingRedrawMsg requests drawing the icons loaded so far after the grid was redrawn.
+type loadingRedrawMsg struct{}
+
+// spinnerTick schedules the next spinner frame.
+func spinnerTick() tea.Cmd {
+	return tea.Tick(spinnerInterval, func(time.Time) tea.Msg { return spinnerTickMsg{} })
+}
+
+// iconLoaded stores an arrived icon. While other icons are still loading, View leaves
+// icon cells alone so its output doesn't change; the icon is drawn into its cell directly.
+// The last icon lets View draw the whole grid once, after which animations start.
+func (m *Model) iconLoaded(msg iconLoadedMsg) tea.Cmd {
+	if msg.Generation != m.IconGen || msg.Index < 0 || msg.Index >= len(m.Icons) || m.Icons[msg.Index] != nil {
+		return nil
+	}
+	m.Icons[msg.Index] = msg.Icon
+	m.IconSources[msg.Index] = msg.Source
+	m.IconsPending--
+	if msg.Children != nil {
+		m.FolderIcons[msg.Index] = msg.Children
+		if m.Folder != nil && m.FolderIndex == msg.Index {
+			// Its panel is part of View, which redraws it with the icons
+			m.Folder = m.newFolder(msg.Index)
+		}
+	}
+
+	if m.IconsPending > 0 {
+		m.writeDirect(m.loadingOutput(msg.Index))
+		return nil
+	}
+	animate := m.startAnimations()
+	return tea.Batch(redrawBadges(), animate)
+}
+
+// redrawWhileLoading schedules drawing the icons loaded so far, which a full View redraw
+// erases while others are still loading. It returns nil once every icon has arrived.
+func (m *Model) redrawWhileLoading() tea.Cmd {
+	if m.IconsPending == 0 {
+		return nil
+	}
+	return tea.Tick(badgeRedrawDelay, func(time.Time) tea.Msg { return loadingRedrawMsg{} })
+}
+
+// drawLoading draws every loaded icon and the spinners of the others.
+func (m *Model) drawLoading() {
+	if m.IconsPending == 0 {
+		return
+	}
+	var b strings.Builder
+	start, end := m.pageApps()
+	for i := start; i < end; i++ {
+		b.WriteString(m.loadingOutput(i))
+	}
+	m.writeDirect(b.String())
+}
+
+// stepSpinners advances the spinners of the icons still loading.
+// Ticking stops once every icon has arrived.
+func (m *Model) stepSpinners() tea.Cmd {
+	if m.IconsPending == 0 {
+		return nil
+	}
+	m.Spinner = (m.Spinner + 1) % len(spinnerFrames)
+
+	var b strings.Builder
+	start, end := m.pageApps()
+	for i := start; i < end; i++ {
+		if m.Icons[i] == nil {
+			b.WriteString(m.loadingOutput(i))
+		}
+	}
+	m.writeDirect(b.String())
+	return spinnerTick()
+}
+
+// loadingOutput returns the ANSI sequence drawing an app's icon if it has loaded,
+// or its spinner otherwise. The icon erases the spinner cell it is drawn over.
+func (m *Model) loadingOutput(index int) string {
+	if !m.Ready || !m.onPage(index) || m.Folder != nil {
+		return ""
+	}
+	cellW, cellH := m.GridCellSize()
+	if cellW <= 0 || cellH <= 0 {
+		return ""
+	}
+
+	// The center of the icon area, where a zero-sized payload would be drawn
+	x, y := m.iconOrigin(index, 0, 0)
+	if m.Icons[index] == nil {
+		return fmt.Sprintf(cursorTo+"\x1b[38;5;%sm%s\x1b[0m", y, x, m.Config.GetBorderColor(), spinnerFrames[m.Spinner])
+	}
+
+	w, h, scale := m.scaledIconCells(index)
+	payload := m.getSixelContentWithDimensions(index, w, h, scale)
+	if payload.Data == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, cursorTo+" ", y, x)
+	x, y = m.iconOrigin(index, payload.Width, payload.Height)
+	fmt.Fprintf(&b, cursorTo, y, x)
+	b.WriteString(payload.Data)
+	if m.Config.GetLabels() == "overlay" {
+		b.WriteString(m.labelOutput(index))
+	}
+	b.WriteString(m.badgeOutput(index))
+	return b.String()
+}
+
+// writeDirect writes output straight to the terminal as one synchronized update,
+// leaving the cursor at the bottom like View does.
+func (m *Model) writeDirect(output string) {
+	if output == "" {
+		return
+	}
+	fmt.Fprint(os.Stdout, syncStart+output+fmt.Sprintf(cursorTo, m.TermHeight, 1)+syncEnd)
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"image"
+	"time"
 
 	"tooie-shelf/internal/config"
 	"tooie-shelf/internal/graphics"
@@ -11,16 +12,50 @@ import (
 // Model represents the application state.
 type Model struct {
 	Config      config.Config
+	Base        config.Config      // Configuration as loaded; Config is it with the active profile applied
+	Profile     int                // Active layout profile (index into Base.Profiles), -1 for none
 	DisplayApps []config.AppConfig // Apps in display order
+	PageBreaks  []int              // Display indices starting a new section (config.PageBreak)
 	TermWidth   int                // Terminal columns
 	TermHeight  int                // Terminal rows
 	CellPx      sys.CellDim        // Pixel dimensions per cell
+	Resize      pendingResize      // Latest size awaiting the resize debounce
+	Rows        int                // Grid rows, with "auto" resolved
+	Columns     int                // Grid columns, with "auto" resolved
+	Layout      []image.Rectangle  // Grid cells of each display index on its page (see pack)
+	Area        image.Rectangle    // Cells the grid is laid out in (0-based), empty for the whole terminal
+	Caps        sys.TerminalCaps   // Capabilities probed at startup
 
-	Icons      []image.Image                  // Original high-res images
-	SixelCache map[string]graphics.SixelResult // Cached sixel data with dimensions
+	Icons        []image.Image                       // Original high-res images
+	IconSources  []string                            // Source identity per icon (see iconSource), keys the on-disk payload cache
+	IconsPending int                                 // Icons still loading; View draws no icon until none are left
+	IconGen      int                                 // Bumped when icons reload for another layout profile; stale icons are dropped
+	Spinner      int                                 // Current frame of the loading spinners
+	Renderer     graphics.Renderer                   // Graphics protocol backend (sixel, kitty)
+	SixelCache   map[string]graphics.Payload         // Cached rendered payloads of the current page
+	PageCaches   map[int]map[string]graphics.Payload // Payload cache of each page visited
 
-	ErrorFlash []bool // Per-app error indicator
-	Selected   int    // Currently selected app index (-1 for none)
+	Background image.Image         // Original wallpaper image (style.background), nil for none
+	Wallpaper  *graphics.Wallpaper // Background cropped to the grid's pixel size
+
+	Animations   map[int]*iconAnimation // Playback state of animated icons by display index
+	AnimationGen int                    // Bumped when animations must be re-encoded; stale ticks are dropped
+	Bounces      map[int]*bounce        // Tap bounce springs by display index, removed once settled
+	BounceGen    int                    // Bumped when bounces are dropped; the running tick loop stops
+
+	Pages []pageRange // Display indices on each page
+	Page  int         // Current page
+	Swipe swipe       // Drag in progress, turning the page on release
+
+	FolderIcons map[int]*folderIcons // Icons of each folder's apps by display index
+	Folder      *Model               // Open folder's panel, nil when closed
+	FolderIndex int                  // Display index of the open folder
+
+	Badges       map[string]int // Notification count per package
+	BadgeBackoff time.Duration  // Delay before retrying after failed polls, 0 after a success
+	BadgeGen     int            // Bumped when polling restarts; the previous poll loop stops
+	ErrorFlash   []bool         // Per-app error indicator
+	Selected     int            // Currently selected app index (-1 for none)
 
 	Ready           bool // Terminal geometry acquired
 	NeedsFullRedraw bool // When true, redraw icons; when false, only redraw borders
@@ -34,21 +69,93 @@ type launchResultMsg struct {
 }
 
 // NewModel creates a new launcher model.
-func NewModel(cfg config.Config) Model {
-	displayApps := cfg.GetDisplayApps()
+// caps are the terminal capabilities probed before the TUI started.
+func NewModel(cfg config.Config, caps sys.TerminalCaps) Model {
+	cfg.LightTerminal = caps.LightBackground()
+
+	// Start with the layout profile for the terminal's size, so icons load for its apps
+	profile := -1
+	if geom, err := sys.GetTerminalGeometry(); err == nil {
+		profile = cfg.ProfileFor(geom.Cols, geom.Rows)
+	}
+	active, err := cfg.WithProfile(profile)
+	if err != nil {
+		// Profiles are validated on load
+		active, profile = cfg, -1
+	}
+	displayApps, breaks := displaySections(active)
 	numApps := len(displayApps)
 
-	return Model{
-		Config:          cfg,
+	m := Model{
+		Config:          active,
+		Base:            cfg,
+		Profile:         profile,
 		DisplayApps:     displayApps,
+		PageBreaks:      breaks,
+		Caps:            caps,
 		Icons:           make([]image.Image, numApps),
-		SixelCache:      make(map[string]graphics.SixelResult),
+		IconSources:     make([]string, numApps),
+		IconsPending:    numApps,
+		Renderer:        graphics.NewRenderer(active.GetGraphics(), caps, sixelOptions(active)),
+		Bounces:         make(map[int]*bounce),
+		FolderIcons:     make(map[int]*folderIcons),
 		ErrorFlash:      make([]bool, numApps),
 		Selected:        -1,
 		Ready:           false,
 		NeedsFullRedraw: true,
 		SixelsDrawn:     false,
 	}
+	m.updateGrid()
+	m.ClearCache()
+	return m
+}
+
+// displaySections returns the apps in display order and the display indices where
+// sections after the first start (see config.PageBreak).
+func displaySections(cfg config.Config) (apps []config.AppConfig, breaks []int) {
+	for i, section := range cfg.GetDisplaySections() {
+		if i > 0 {
+			breaks = append(breaks, len(apps))
+		}
+		apps = append(apps, section...)
+	}
+	return apps, breaks
+}
+
+// sixelOptions converts the sixel encoder config to graphics options.
+func sixelOptions(cfg config.Config) graphics.SixelOptions {
+	return graphics.SixelOptions{
+		Colors:    cfg.Style.Sixel.Colors,
+		Quantizer: cfg.Style.Sixel.Quantizer,
+		Dither:    cfg.Style.Sixel.Dither,
+	}
+}
+
+// iconStyle returns the shape and fill applied to the icon at a display index.
+func (m *Model) iconStyle(index int) graphics.IconStyle {
+	if index >= len(m.DisplayApps) {
+		return graphics.IconStyle{Shape: m.Config.Style.IconShape}
+	}
+	app := m.DisplayApps[index]
+	style := graphics.IconStyle{Shape: m.Config.GetIconShape(app), Matte: m.Caps.Background}
+	if bg := m.Config.GetIconBackground(app); bg != "" {
+		if c, err := graphics.ParseHexColor(bg); err == nil {
+			style.Background = c
+		}
+	}
+	return style
+}
+
+// updateWallpaper crops the background to the grid's current pixel size.
+// The wallpaper covers TermHeight-1 rows, like the grid, so drawing it never scrolls.
+func (m *Model) updateWallpaper() {
+	m.Wallpaper = nil
+	if m.Background == nil || !m.Ready {
+		return
+	}
+	w := m.TermWidth * m.CellPx.Width
+	h := (m.TermHeight - 1) * m.CellPx.Height
+	m.Wallpaper = graphics.NewWallpaper(m.Background, w, h)
 }
 
 // CacheKey generates a cache key for a sixel render.
@@ -56,25 +163,109 @@ func CacheKey(appIndex, widthCells, heightCells int) string {
 	return string(rune(appIndex)) + "_" + string(rune(widthCells)) + "_" + string(rune(heightCells))
 }
 
-// ClearCache invalidates all cached sixel data.
+// ClearCache invalidates all cached payload data, on every page.
 func (m *Model) ClearCache() {
-	m.SixelCache = make(map[string]graphics.SixelResult)
+	m.PageCaches = make(map[int]map[string]graphics.Payload)
+	m.SixelCache = m.pageCache(m.Page)
+}
+
+// updateGrid resolves the grid size for the current terminal geometry.
+// Fixed dimensions are used as configured. With one "auto" dimension it grows until
+// every app of each display section packs onto one page; with both, the split whose
+// icons come out largest is chosen. Apps are then packed into pages of the resolved size.
+func (m *Model) updateGrid() {
+	rows, cols := int(m.Config.Grid.Rows), int(m.Config.Grid.Columns)
+	n := max(m.largestSection(), 1)
+
+	switch {
+	case !m.Config.Grid.Rows.IsAuto() && !m.Config.Grid.Columns.IsAuto():
+	case !m.Config.Grid.Columns.IsAuto():
+		rows = m.packedRows(n, cols)
+	case !m.Config.Grid.Rows.IsAuto():
+		cols = (n + rows - 1) / rows
+		// Spans can leave holes no app fits
+		for cols < n && !m.fits(rows, cols) {
+			cols++
+		}
+	default:
+		rows, cols = 1, n
+		best := -1
+		for c := 1; c <= n; c++ {
+			r := m.packedRows(n, c)
+			// Ties go to fewer columns, leaving fewer empty cells in the last row
+			if side := m.autoIconSide(r, c); side > best {
+				rows, cols, best = r, c, side
+			}
+		}
+	}
+	m.Rows, m.Columns = rows, cols
+	m.updatePages()
+}
+
+// packedRows returns the fewest rows of cols columns that every display section packs
+// into, spanning n grid cells at most.
+func (m *Model) packedRows(n, cols int) int {
+	rows := (n + cols - 1) / cols
+	// Spans can leave holes no app fits
+	for rows < n && !m.fits(rows, cols) {
+		rows++
+	}
+	return rows
+}
+
+// autoIconSide returns the pixel side of the largest square icon a rows x cols grid fits,
+// or 0 when its cells are too small to hold one.
+func (m *Model) autoIconSide(rows, cols int) int {
+	// Terminal cells are about twice as tall as wide until the real size is known
+	cellPx := m.CellPx
+	if cellPx.Width <= 0 || cellPx.Height <= 0 {
+		cellPx = sys.CellDim{Width: 1, Height: 2}
+	}
+
+	chrome := 2 * m.Config.Style.Padding
+	if m.Config.Style.Border {
+		chrome += 2
+	}
+	area := m.gridArea()
+	w := area.Dx()/cols - chrome
+	h := area.Dy()/rows - chrome - m.labelRows()
+	if w < 1 || h < 1 {
+		return 0
+	}
+	return min(w*cellPx.Width, h*cellPx.Height)
 }
 
 // GridCellSize calculates the size of each grid cell in terminal cells.
 func (m *Model) GridCellSize() (width, height int) {
-	if m.Config.Grid.Columns <= 0 || m.Config.Grid.Rows <= 0 {
+	if m.Columns <= 0 || m.Rows <= 0 {
 		return 0, 0
 	}
-	width = m.TermWidth / m.Config.Grid.Columns
-	// Use TermHeight - 1 to avoid bottom border being cut off
-	height = (m.TermHeight - 1) / m.Config.Grid.Rows
+	area := m.gridArea()
+	width = area.Dx() / m.Columns
+	height = area.Dy() / m.Rows
 	return
 }
 
-// IconCellSize calculates the available space for icons within a cell.
-func (m *Model) IconCellSize() (width, height int) {
-	cellW, cellH := m.GridCellSize()
+// gridArea returns the terminal cells (0-based) the grid is laid out in: Area when set,
+// such as an open folder's panel, else the whole terminal except its bottom row,
+// which avoids the bottom border being cut off.
+func (m *Model) gridArea() image.Rectangle {
+	if !m.Area.Empty() {
+		return m.Area
+	}
+	return image.Rect(0, 0, m.TermWidth, m.TermHeight-1)
+}
+
+// cellRect returns the terminal cells (0-based) an app covers on its page,
+// all the grid cells of its span.
+func (m *Model) cellRect(index int) image.Rectangle {
+	return m.gridRect(m.Layout[index])
+}
+
+// IconCellSize calculates the available space for an app's icon within its cells.
+func (m *Model) IconCellSize(index int) (width, height int) {
+	r := m.cellRect(index)
+	cellW, cellH := r.Dx(), r.Dy()
 
 	// Subtract padding and borders
 	padding := m.Config.Style.Padding
@@ -84,7 +275,7 @@ func (m *Model) IconCellSize() (width, height int) {
 	}
 
 	width = cellW - 2*padding - borderSize
-	height = cellH - 2*padding - borderSize
+	height = cellH - 2*padding - borderSize - m.labelRows()
 
 	if width < 1 {
 		width = 1
@@ -95,6 +286,27 @@ func (m *Model) IconCellSize() (width, height int) {
 	return
 }
 
+// labelRows returns the number of rows reserved below the icon for its label.
+func (m *Model) labelRows() int {
+	if m.Config.GetLabels() == "below" {
+		return 1
+	}
+	return 0
+}
+
+// labelRow returns the row of an app's label inside its frame (0-based, below the border),
+// or -1 when labels are disabled.
+func (m *Model) labelRow(index int) int {
+	_, iconH := m.IconCellSize(index)
+	switch m.Config.GetLabels() {
+	case "below":
+		return m.Config.Style.Padding + iconH
+	case "overlay":
+		return m.Config.Style.Padding + iconH - 1
+	}
+	return -1
+}
+
 // HitTest returns the app index at the given terminal coordinates, or -1 if none.
 func (m *Model) HitTest(x, y int) int {
 	cellW, cellH := m.GridCellSize()
@@ -102,22 +314,19 @@ func (m *Model) HitTest(x, y int) int {
 		return -1
 	}
 
-	col := x / cellW
-	row := y / cellH
-
-	if col < 0 || col >= m.Config.Grid.Columns {
-		return -1
-	}
-	if row < 0 || row >= m.Config.Grid.Rows {
+	area := m.gridArea()
+	if x < area.Min.X || y < area.Min.Y {
 		return -1
 	}
+	cell := image.Pt((x-area.Min.X)/cellW, (y-area.Min.Y)/cellH)
 
-	index := row*m.Config.Grid.Columns + col
-	if index >= len(m.DisplayApps) {
-		return -1
+	start, end := m.pageApps()
+	for i := start; i < end; i++ {
+		if cell.In(m.Layout[i]) {
+			return i
+		}
 	}
-
-	return index
+	return -1
 }
 
 // GetIconScale returns the icon scale for the app at the given display index.
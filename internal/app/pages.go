@@ -0,0 +1,163 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"tooie-shelf/internal/graphics"
+)
+
+// pageRange is the span of display indices shown on one page.
+type pageRange struct {
+	Start int // First display index
+	End   int // One past the last display index
+}
+
+// swipe tracks a mouse drag from press to release.
+type swipe struct {
+	StartX, StartY int // Press position
+	X, Y           int // Latest motion position
+	Active         bool
+}
+
+// updatePages packs the apps into pages of one grid each (see pack). Apps overflowing
+// the grid flow onto further pages, and every display section (see config.PageBreak)
+// starts a new one.
+func (m *Model) updatePages() {
+	m.Layout = make([]image.Rectangle, len(m.DisplayApps))
+	var pages []pageRange
+	bounds := m.sectionBounds()
+	for i := 0; i+1 < len(bounds); i++ {
+		pages = append(pages, m.pack(bounds[i], bounds[i+1], m.Rows, m.Columns, m.Layout)...)
+	}
+	if len(pages) == 0 {
+		pages = []pageRange{{}}
+	}
+	m.Pages = pages
+	m.Page = min(m.Page, len(pages)-1)
+}
+
+// sectionBounds returns the display indices where sections start, followed by the app count.
+func (m *Model) sectionBounds() []int {
+	bounds := append([]int{0}, m.PageBreaks...)
+	return append(bounds, len(m.DisplayApps))
+}
+
+// largestSection returns the number of grid cells the apps of the largest display
+// section span, before spans are clamped to the grid.
+func (m *Model) largestSection() int {
+	n := 0
+	bounds := m.sectionBounds()
+	for i := 0; i+1 < len(bounds); i++ {
+		cells := 0
+		for j := bounds[i]; j < bounds[i+1]; j++ {
+			rows, cols := m.DisplayApps[j].GetSpan()
+			cells += rows * cols
+		}
+		n = max(n, cells)
+	}
+	return n
+}
+
+// pageApps returns the range of display indices on the current page.
+func (m *Model) pageApps() (start, end int) {
+	if m.Page >= len(m.Pages) {
+		return 0, 0
+	}
+	p := m.Pages[m.Page]
+	return p.Start, p.End
+}
+
+// onPage reports whether the app at a display index is on the current page.
+func (m *Model) onPage(index int) bool {
+	start, end := m.pageApps()
+	return index >= start && index < end
+}
+
+// pageCache returns the payload cache of a page, creating it on first use.
+func (m *Model) pageCache(page int) map[string]graphics.Payload {
+	cache, ok := m.PageCaches[page]
+	if !ok {
+		cache = make(map[string]graphics.Payload)
+		m.PageCaches[page] = cache
+	}
+	return cache
+}
+
+// setPage switches to another page, clamped to the first and last one.
+// The page's payloads stay cached, so flipping back doesn't render them again.
+// Pages don't turn under an open folder.
+func (m *Model) setPage(page int) tea.Cmd {
+	page = max(0, min(page, len(m.Pages)-1))
+	if page == m.Page || m.Folder != nil {
+		return nil
+	}
+	m.Page = page
+	m.SixelCache = m.pageCache(page)
+	return m.redrawGrid()
+}
+
+// redrawGrid clears the screen so View draws the grid from scratch, after the apps
+// on screen changed. Bounces stop and animations restart with the new apps.
+func (m *Model) redrawGrid() tea.Cmd {
+	m.Bounces = make(map[int]*bounce)
+	m.BounceGen++
+	m.SixelsDrawn = false
+	animate := m.startAnimations()
+	return tea.Batch(tea.ClearScreen, redrawBadges(), animate, m.redrawWhileLoading())
+}
+
+// swipeDistance is how far in columns a drag must move horizontally to turn the page.
+func (m *Model) swipeDistance() int {
+	return max(3, m.TermWidth/6)
+}
+
+// endSwipe finishes a drag at the release position. It returns the page a horizontal
+// swipe turns to (swiping left shows the next page), and whether the gesture was a drag
+// rather than a tap: a drag that wandered off the pressed cell launches nothing.
+func (m *Model) endSwipe(x, y int) (page int, dragged bool) {
+	s := m.Swipe
+	m.Swipe = swipe{}
+	if !s.Active {
+		return m.Page, false
+	}
+
+	dx, dy := x-s.StartX, y-s.StartY
+	if abs(dx) >= m.swipeDistance() && abs(dx) > abs(dy) {
+		if dx < 0 {
+			return m.Page + 1, true
+		}
+		return m.Page - 1, true
+	}
+	pressed := m.HitTest(s.StartX, s.StartY)
+	return m.Page, pressed != m.HitTest(s.X, s.Y) || pressed != m.HitTest(x, y)
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// pageIndicator returns the ANSI sequence drawing one dot per page, centered on the
+// bottom row below the grid, or "" when there is a single page.
+func (m *Model) pageIndicator() string {
+	if len(m.Pages) < 2 {
+		return ""
+	}
+	dots := make([]string, len(m.Pages))
+	for i := range dots {
+		dots[i] = fmt.Sprintf("\x1b[38;5;%sm○", m.Config.GetBorderColor())
+		if i == m.Page {
+			dots[i] = fmt.Sprintf("\x1b[38;5;%sm●", m.Config.GetHighlightColor())
+		}
+	}
+	width := 2*len(dots) - 1
+	x := max((m.TermWidth-width)/2+1, 1)
+	return fmt.Sprintf(cursorTo, m.TermHeight, x) + strings.Join(dots, " ") + "\x1b[0m"
+}
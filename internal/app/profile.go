@@ -0,0 +1,104 @@
+package app
+
+import (
+	"fmt"
+	"image"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"tooie-shelf/internal/config"
+	"tooie-shelf/internal/graphics"
+)
+
+// loadedIcon is an icon kept across a layout profile switch.
+type loadedIcon struct {
+	Icon     image.Image
+	Source   string
+	Children *folderIcons
+}
+
+// updateProfile switches to the layout profile matching the terminal's size.
+// It returns nil when that profile is already active.
+func (m *Model) updateProfile() tea.Cmd {
+	profile := m.Base.ProfileFor(m.TermWidth, m.TermHeight)
+	if profile == m.Profile {
+		return nil
+	}
+	return m.switchProfile(profile)
+}
+
+// switchProfile applies a layout profile (-1 for the top-level layout) and redraws the grid.
+// Icons already loaded are kept for the apps still shown, unless the profile changes
+// how icons are loaded; the others load like at startup.
+func (m *Model) switchProfile(profile int) tea.Cmd {
+	cfg, err := m.Base.WithProfile(profile)
+	if err != nil {
+		// Profiles are validated on load
+		return nil
+	}
+	prev := m.Config
+	m.Config, m.Profile = cfg, profile
+
+	loaded := make(map[string]loadedIcon)
+	if iconSources(prev.Style) == iconSources(cfg.Style) {
+		for i, app := range m.DisplayApps {
+			if m.Icons[i] != nil {
+				loaded[app.Name] = loadedIcon{Icon: m.Icons[i], Source: m.IconSources[i], Children: m.FolderIcons[i]}
+			}
+		}
+	}
+
+	// Display indices change: reset everything kept by index
+	wasLoading := m.IconsPending > 0
+	m.DisplayApps, m.PageBreaks = displaySections(cfg)
+	n := len(m.DisplayApps)
+	m.Icons = make([]image.Image, n)
+	m.IconSources = make([]string, n)
+	m.FolderIcons = make(map[int]*folderIcons)
+	m.ErrorFlash = make([]bool, n)
+	m.IconsPending = 0
+	m.IconGen++
+	for i, app := range m.DisplayApps {
+		icon, ok := loaded[app.Name]
+		if !ok {
+			m.IconsPending++
+			continue
+		}
+		m.Icons[i], m.IconSources[i] = icon.Icon, icon.Source
+		if icon.Children != nil {
+			m.FolderIcons[i] = icon.Children
+		}
+	}
+	m.Selected = -1
+	m.Folder = nil
+	m.Page = 0
+	m.Swipe = swipe{}
+
+	m.Renderer = graphics.NewRenderer(cfg.GetGraphics(), m.Caps, sixelOptions(cfg))
+	m.updateGrid()
+	m.ClearCache()
+
+	cmds := []tea.Cmd{loadIcons(m.DisplayApps, m.Icons, cfg, m.IconGen)}
+	if m.IconsPending > 0 && !wasLoading {
+		cmds = append(cmds, spinnerTick())
+	}
+	if cfg.Style.Background != prev.Style.Background {
+		m.Background = nil
+		if cfg.Style.Background != "" {
+			cmds = append(cmds, loadBackground(cfg.Style.Background, cfg.GetIconTimeout()))
+		}
+	}
+	m.updateWallpaper()
+	if prev.GetBadges() == "none" && cfg.GetBadges() != "none" {
+		// A poll scheduled before badges were turned off may still be pending
+		m.BadgeGen++
+		m.BadgeBackoff = 0
+		cmds = append(cmds, pollBadges(m.BadgeGen, 0))
+	}
+	return tea.Batch(append(cmds, m.redrawGrid())...)
+}
+
+// iconSources identifies the style fields icons are loaded with (see loadAppIcon).
+func iconSources(s config.StyleConfig) string {
+	return fmt.Sprintf("%q %q %q %q", s.IconPack, s.IconShape, s.IconTheme, s.IconPalette)
+}
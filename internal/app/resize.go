@@ -0,0 +1,80 @@
+package app
+
+import (
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pendingResize is the latest terminal size, waiting for the resize debounce.
+type pendingResize struct {
+	Width  int
+	Height int
+	Seq    int // Bumped per resize; only the last one's resizeSettledMsg is acted on
+}
+
+// resizeSettledMsg fires a debounce after a resize.
+type resizeSettledMsg struct {
+	Seq int
+}
+
+// resized records a new terminal size and waits for it to settle: rotating the device
+// or dragging a pane sends a burst of sizes, and only the last one is acted on.
+func (m *Model) resized(msg resizeMsg) tea.Cmd {
+	m.Resize.Seq++
+	m.Resize.Width, m.Resize.Height = msg.Width, msg.Height
+	seq := m.Resize.Seq
+	return tea.Tick(m.Config.GetResizeDebounce(), func(time.Time) tea.Msg { return resizeSettledMsg{Seq: seq} })
+}
+
+// resizeSettled applies the resize policy to a size that held for the debounce.
+// A size the soft keyboard explains is ignored, leaving the grid under the keyboard
+// as it was; any other size lays the grid out again from scratch.
+func (m *Model) resizeSettled(msg resizeSettledMsg) tea.Cmd {
+	if msg.Seq != m.Resize.Seq {
+		return nil
+	}
+	w, h := m.Resize.Width, m.Resize.Height
+	if (w == m.TermWidth && h == m.TermHeight) || m.isKeyboard(w, h) {
+		return nil
+	}
+
+	m.TermWidth, m.TermHeight = w, h
+	// The cell pixel size may change with the window too
+	if profile := m.updateProfile(); profile != nil {
+		return tea.Batch(profile, queryTerminal(m.Caps))
+	}
+	return tea.Batch(m.relayout(), queryTerminal(m.Caps))
+}
+
+// isKeyboard reports whether resizing to w x h looks like the soft keyboard opening:
+// the width stays and the height shrinks by a share of the rows within the
+// behavior.resize keyboard bounds. Only Termux outside tmux has a soft keyboard to
+// open; elsewhere such a shrink is a pane split and must be laid out.
+func (m *Model) isKeyboard(w, h int) bool {
+	if !hasSoftKeyboard() || w != m.TermWidth || h >= m.TermHeight || m.TermHeight <= 0 {
+		return false
+	}
+	lo, hi := m.Config.GetKeyboardShrink()
+	shrink := float64(m.TermHeight-h) / float64(m.TermHeight)
+	return shrink >= lo && shrink <= hi
+}
+
+// hasSoftKeyboard reports whether the terminal is Termux's own window, which the soft
+// keyboard shrinks, rather than a tmux pane that splits resize as well.
+func hasSoftKeyboard() bool {
+	return os.Getenv("TERMUX_VERSION") != "" && os.Getenv("TMUX") == ""
+}
+
+// relayout lays the grid out again for the current terminal geometry, flushing every
+// cached payload, and redraws the screen with the images at their new positions.
+func (m *Model) relayout() tea.Cmd {
+	m.updateGrid()
+	m.ClearCache()
+	m.updateWallpaper()
+	if m.Folder != nil {
+		m.Folder = m.newFolder(m.FolderIndex)
+	}
+	return m.redrawGrid()
+}
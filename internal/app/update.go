@@ -1,9 +1,11 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -16,10 +18,20 @@ import (
 
 // Init initializes the model.
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(
-		queryTerminal,
-		loadIcons(m.DisplayApps),
-	)
+	cmds := []tea.Cmd{
+		queryTerminal(m.Caps),
+		loadIcons(m.DisplayApps, m.Icons, m.Config, m.IconGen),
+	}
+	if m.IconsPending > 0 {
+		cmds = append(cmds, spinnerTick())
+	}
+	if m.Config.Style.Background != "" {
+		cmds = append(cmds, loadBackground(m.Config.Style.Background, m.Config.GetIconTimeout()))
+	}
+	if m.Config.GetBadges() != "none" {
+		cmds = append(cmds, pollBadges(m.BadgeGen, 0))
+	}
+	return tea.Batch(cmds...)
 }
 
 // Update handles events and updates the model.
@@ -27,19 +39,37 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "q", "ctrl+c", "esc":
+		case "q", "ctrl+c":
 			return m, tea.Quit
+		case "esc":
+			if m.Folder != nil {
+				closeFolder := m.closeFolder()
+				return m, closeFolder
+			}
+			return m, tea.Quit
+		case "pgdown":
+			page := m.setPage(m.Page + 1)
+			return m, page
+		case "pgup":
+			page := m.setPage(m.Page - 1)
+			return m, page
 		}
 
-	case tea.WindowSizeMsg:
-		// Only set dimensions on first receive, ignore resizes (e.g., soft keyboard)
+	case resizeMsg:
+		// The first size is the initial layout
 		if m.TermWidth == 0 && m.TermHeight == 0 {
 			m.TermWidth = msg.Width
 			m.TermHeight = msg.Height
-			return m, queryTerminal
+			profile := m.updateProfile()
+			return m, tea.Batch(queryTerminal(m.Caps), profile)
 		}
-		// Ignore subsequent resize events to prevent redraws
-		return m, nil
+		// Later ones go through the resize policy once they settle (e.g., soft keyboard)
+		settle := m.resized(msg)
+		return m, settle
+
+	case resizeSettledMsg:
+		cmd := m.resizeSettled(msg)
+		return m, cmd
 
 	case terminalGeometryMsg:
 		// Debounce: only update if dimensions actually changed
@@ -50,35 +80,110 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.CellPx = msg.CellDim
 		m.Ready = true
+		relayout := m.relayout()
+		return m, relayout
+
+	case iconLoadedMsg:
+		cmd := m.iconLoaded(msg)
+		return m, cmd
+
+	case spinnerTickMsg:
+		tick := m.stepSpinners()
+		return m, tick
+
+	case loadingRedrawMsg:
+		m.drawLoading()
+		return m, nil
+
+	case backgroundLoadedMsg:
+		if msg.Source != m.Config.Style.Background {
+			// Loaded for a layout profile no longer active
+			return m, nil
+		}
+		m.Background = msg.Image
+		m.updateWallpaper()
+		// Icons are composited over the wallpaper, so every payload changes
 		m.ClearCache()
-		m.SixelsDrawn = false // Force sixel redraw at new positions
-		return m, tea.ClearScreen
+		m.SixelsDrawn = false
+		animate := m.startAnimations()
+		return m, tea.Batch(redrawBadges(), animate, m.redrawWhileLoading())
 
-	case iconsLoadedMsg:
-		m.Icons = msg.Icons
+	case animationsEncodedMsg:
+		if msg.Generation != m.AnimationGen {
+			return m, nil
+		}
+		tick := m.playAnimations(msg.Animations)
+		return m, tick
+
+	case animationTickMsg:
+		if msg.Generation != m.AnimationGen {
+			return m, nil
+		}
+		tick := m.advanceAnimations()
+		return m, tick
+
+	case badgesMsg:
+		if msg.Generation != m.BadgeGen || m.Config.GetBadges() == "none" {
+			// A profile switch turned badges off or restarted polling; this loop ends
+			return m, nil
+		}
+		if msg.Err != nil {
+			return m, m.badgesFailed(msg.Err)
+		}
+		m.BadgeBackoff = 0
+		m.updateBadges(msg.Counts)
+		return m, pollBadges(m.BadgeGen, m.Config.GetBadgeInterval())
+
+	case bounceTickMsg:
+		if msg.Generation != m.BounceGen {
+			// Bounces were dropped since; a newer loop steps the current ones
+			return m, nil
+		}
+		tick := m.stepBounces()
+		return m, tick
+
+	case badgesRedrawMsg:
+		m.drawAllBadges()
+		return m, nil
 
 	case tea.MouseMsg:
-		// Only handle release events, ignore press/motion to avoid extra redraws
-		if msg.Action != tea.MouseActionRelease {
+		// Press and motion only track a possible swipe; taps act on release
+		switch msg.Action {
+		case tea.MouseActionPress:
+			if msg.Button == tea.MouseButtonLeft {
+				m.Swipe = swipe{StartX: msg.X, StartY: msg.Y, X: msg.X, Y: msg.Y, Active: true}
+			}
+			return m, nil
+		case tea.MouseActionMotion:
+			if m.Swipe.Active {
+				m.Swipe.X, m.Swipe.Y = msg.X, msg.Y
+			}
 			return m, nil
 		}
+		if m.Folder != nil {
+			m.Swipe = swipe{}
+			tap := m.folderTap(msg.X, msg.Y)
+			return m, tap
+		}
+		if page, dragged := m.endSwipe(msg.X, msg.Y); dragged {
+			turn := m.setPage(page)
+			return m, turn
+		}
 		index := m.HitTest(msg.X, msg.Y)
 		if index >= 0 && index < len(m.DisplayApps) {
-			// Flash visual feedback directly via ANSI (no View() redraw)
-			m.flashCell(index)
-
 			app := m.DisplayApps[index]
-			if app.IsCommand() {
-				// Run command/script/binary
-				go sys.RunCommand(app.Command)
-			} else {
-				// Launch Android app
-				go sys.LaunchApp(app.Package, app.Activity)
+			if app.IsFolder() {
+				open := m.openFolder(index)
+				return m, open
 			}
 
-			if m.Config.Behavior.CloseOnLaunch {
-				return m, tea.Quit
+			// Flash visual feedback directly via ANSI (no View() redraw)
+			m.flashCell(index)
+			bounce := m.startBounce(index)
+			if launch := m.launch(app); launch != nil {
+				return m, launch
 			}
+			return m, bounce
 		}
 		return m, nil
 	}
@@ -86,26 +191,65 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// launch starts an app in the background, quitting afterwards when close_on_launch is set.
+func (m *Model) launch(app config.AppConfig) tea.Cmd {
+	if app.IsCommand() {
+		// Run command/script/binary
+		go sys.RunCommand(app.Command)
+	} else {
+		// Launch Android app
+		go sys.LaunchApp(app.Package, app.Activity)
+	}
+
+	if m.Config.Behavior.CloseOnLaunch {
+		return tea.Quit
+	}
+	return nil
+}
+
+// resizeMsg is a tea.WindowSizeMsg, renamed by Filter.
+type resizeMsg struct {
+	Width  int
+	Height int
+}
+
+// Filter is the program's message filter (see tea.WithFilter). It keeps window sizes
+// from Bubble Tea's renderer, which would otherwise truncate the last line of View to
+// the terminal width and erase the rest of that row. That line carries the absolutely
+// positioned icons, labels and overlays of the whole grid, so the model gets sizes
+// as resizeMsg instead.
+func Filter(_ tea.Model, msg tea.Msg) tea.Msg {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		return resizeMsg{Width: size.Width, Height: size.Height}
+	}
+	return msg
+}
+
 // terminalGeometryMsg carries terminal pixel dimensions.
 type terminalGeometryMsg struct {
 	CellDim sys.CellDim
 }
 
-// iconsLoadedMsg carries loaded icon images.
-type iconsLoadedMsg struct {
-	Icons []image.Image
+// backgroundLoadedMsg carries the decoded wallpaper image and the style.background it came from.
+type backgroundLoadedMsg struct {
+	Image  image.Image
+	Source string
 }
 
-// queryTerminal queries terminal geometry.
-func queryTerminal() tea.Msg {
-	geom, err := sys.GetTerminalGeometry()
-	if err != nil {
-		// Use fallback dimensions
-		return terminalGeometryMsg{
-			CellDim: sys.CellDim{Width: 10, Height: 20},
+// queryTerminal queries terminal geometry, using probed caps when ioctl lacks pixel sizes.
+func queryTerminal(caps sys.TerminalCaps) tea.Cmd {
+	return func() tea.Msg {
+		geom, err := sys.GetTerminalGeometryWithCaps(caps)
+		if err != nil {
+			// Use probed or fallback dimensions
+			cellDim := sys.CellDim{Width: 10, Height: 20}
+			if caps.CellPx.Width > 0 && caps.CellPx.Height > 0 {
+				cellDim = caps.CellPx
+			}
+			return terminalGeometryMsg{CellDim: cellDim}
 		}
+		return terminalGeometryMsg{CellDim: geom.CellDim}
 	}
-	return terminalGeometryMsg{CellDim: geom.CellDim}
 }
 
 // loadIcons loads all icon images for the display apps in parallel.
@@ -113,55 +257,146 @@ func queryTerminal() tea.Msg {
 // 1. User-specified Dashboard Icons (icon: "dashboard:icon-name")
 // 2. User-specified URL (icon: "https://...")
 // 3. User-specified local file path
-// 4. Cached/extracted APK icon (if package specified and no user icon)
-// 5. Placeholder (fallback)
-func loadIcons(apps []config.AppConfig) tea.Cmd {
-	return func() tea.Msg {
-		type iconResult struct {
-			index int
-			img   image.Image
+// 4. Icon pack icon (if style.icon_pack is set and the pack has one for the app)
+// 5. Cached/extracted APK icon (if package specified and no user icon)
+// 6. Placeholder (fallback)
+//
+// Icons load in parallel, each reported by its own iconLoadedMsg as soon as it is ready.
+// Apps whose icon is already in icons are skipped. gen is the model's IconGen.
+func loadIcons(apps []config.AppConfig, icons []image.Image, cfg config.Config, gen int) tea.Cmd {
+	cmds := []tea.Cmd{func() tea.Msg {
+		// Drop stale payloads while icons load
+		graphics.PrunePayloadCache()
+		return nil
+	}}
+
+	timeout := cfg.GetIconTimeout()
+	for i, app := range apps {
+		if icons[i] != nil {
+			continue
 		}
+		i, app := i, app
+		cmds = append(cmds, func() tea.Msg {
+			if app.IsFolder() {
+				img, children := loadFolder(app, cfg, timeout)
+				return iconLoadedMsg{Generation: gen, Index: i, Icon: img, Source: folderSource(app, cfg, children), Children: children}
+			}
+			if source, known := iconSource(app, cfg); known {
+				// Decoded only if a payload isn't cached on disk yet
+				icon := graphics.NewLazyIcon(func() image.Image { return loadAppIcon(app, cfg, timeout) })
+				return iconLoadedMsg{Generation: gen, Index: i, Icon: icon, Source: source}
+			}
+			img := loadAppIcon(app, cfg, timeout)
+			source, _ := iconSource(app, cfg)
+			return iconLoadedMsg{Generation: gen, Index: i, Icon: img, Source: source}
+		})
+	}
+	return tea.Batch(cmds...)
+}
 
-		icons := make([]image.Image, len(apps))
-		resultChan := make(chan iconResult, len(apps))
+// loadAppIcon loads and themes the icon of one app.
+func loadAppIcon(app config.AppConfig, cfg config.Config, timeout time.Duration) image.Image {
+	shape := cfg.GetIconShape(app)
+	img, adaptive := loadSingleIcon(app, shape, cfg.Style.IconPack, timeout)
+	return themeIcon(img, adaptive, shape, cfg.GetIconTheme(app), cfg.Style.IconPalette)
+}
 
-		// Launch goroutines for parallel loading
-		for i, app := range apps {
-			go func(index int, app config.AppConfig) {
-				img := loadSingleIcon(app)
-				resultChan <- iconResult{index: index, img: img}
-			}(i, app)
-		}
+// iconSource identifies the icon loadAppIcon produces for an app without loading it,
+// keying its payloads on disk: the path, size and modification time of the files its
+// sources are read from, along with its styling. known is false while the icon can't
+// be identified up front, as when a source isn't downloaded or extracted yet or may
+// be animated; the icon then has to load, and its key is only final afterwards.
+func iconSource(app config.AppConfig, cfg config.Config) (source string, known bool) {
+	var parts []string
+	known = true
+	// add records a source in loadSingleIcon's order, reporting whether it is used
+	add := func(kind, stamp string, still bool) bool {
+		parts = append(parts, kind+":"+stamp)
+		known = known && still && stamp != ""
+		return stamp != ""
+	}
 
-		// Collect results
-		for range apps {
-			result := <-resultChan
-			icons[result.index] = result.img
+	found := false
+	if app.Icon != "" {
+		switch {
+		case strings.HasPrefix(app.Icon, "dashboard:"):
+			stamp, still := graphics.URLIconStamp(graphics.DashboardIconURL(strings.TrimPrefix(app.Icon, "dashboard:")))
+			found = add("url", stamp, still)
+		case strings.HasPrefix(app.Icon, "http://") || strings.HasPrefix(app.Icon, "https://"):
+			stamp, still := graphics.URLIconStamp(app.Icon)
+			found = add("url", stamp, still)
+		default:
+			switch strings.ToLower(filepath.Ext(app.Icon)) {
+			case ".png", ".jpg", ".jpeg", ".svg":
+				found = add("file", graphics.FileStamp(app.Icon), true)
+			default:
+				// GIFs and WebPs may be animated
+				found = add("file", graphics.FileStamp(app.Icon), false)
+			}
 		}
+	}
+	if !found && cfg.Style.IconPack != "" && app.Package != "" {
+		found = add("pack", graphics.PackIconStamp(cfg.Style.IconPack, app.Package, app.Activity), true)
+	}
+	if !found && app.Package != "" {
+		found = add("apk", graphics.APKIconStamp(app.Package), true)
+	}
+	parts = append(parts, fmt.Sprintf("%q|%q|%q", cfg.GetIconShape(app), cfg.GetIconTheme(app), cfg.Style.IconPalette))
+	return strings.Join(parts, "|"), known
+}
 
-		return iconsLoadedMsg{Icons: icons}
+// loadBackground loads the wallpaper from a local path or URL, giving a download up to timeout.
+func loadBackground(src string, timeout time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		var img image.Image
+		var err error
+		if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			img, err = graphics.FetchIconFromURL(ctx, src)
+			cancel()
+		} else {
+			img, err = graphics.LoadImage(src)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load background '%s': %v\n", src, err)
+			return nil
+		}
+		return backgroundLoadedMsg{Image: img, Source: src}
 	}
 }
 
 // loadSingleIcon loads a single icon for an app.
-func loadSingleIcon(app config.AppConfig) image.Image {
+// shape is the app's icon mask; adaptive APK icons default to a circle when it is empty.
+// The adaptive icon layers are returned alongside the image when the icon came from one.
+// iconPack is the package of an icon pack to prefer over the app's own icon, if any.
+// Each source is canceled after timeout, falling through to the next one.
+func loadSingleIcon(app config.AppConfig, shape, iconPack string, timeout time.Duration) (image.Image, *graphics.AdaptiveIcon) {
+	var adaptive *graphics.AdaptiveIcon
 	var img image.Image
 	var err error
 
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer func() { cancel() }()
+	// next gives the following source a fresh deadline
+	next := func() {
+		cancel()
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	}
+
 	// Priority 1, 2, 3: User-specified icon takes priority
 	if app.Icon != "" {
 		switch {
 		// Dashboard Icons: "dashboard:icon-name"
 		case strings.HasPrefix(app.Icon, "dashboard:"):
 			iconName := strings.TrimPrefix(app.Icon, "dashboard:")
-			img, err = graphics.FetchDashboardIcon(iconName)
+			img, err = graphics.FetchDashboardIcon(ctx, iconName)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: failed to fetch dashboard icon '%s': %v\n", iconName, err)
 			}
 
 		// Direct URL: "https://..."
 		case strings.HasPrefix(app.Icon, "http://") || strings.HasPrefix(app.Icon, "https://"):
-			img, err = graphics.FetchIconFromURL(app.Icon)
+			img, err = graphics.FetchIconFromURL(ctx, app.Icon)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: failed to fetch icon from URL '%s': %v\n", app.Icon, err)
 			}
@@ -175,9 +410,16 @@ func loadSingleIcon(app config.AppConfig) image.Image {
 		}
 	}
 
-	// Priority 4: If no user-specified icon loaded, try APK extraction (uses cache)
+	// Priority 4: Icon pack (uses cache); apps missing from the pack fall through to their own icon
+	if img == nil && iconPack != "" && app.Package != "" {
+		next()
+		img, _ = graphics.ExtractIconPackIcon(ctx, iconPack, app.Package, app.Activity)
+	}
+
+	// Priority 5: If no user-specified icon loaded, try APK extraction (uses cache)
 	if img == nil && app.Package != "" {
-		img, err = graphics.ExtractAPKIcon(app.Package)
+		next()
+		img, adaptive, err = graphics.ExtractAPKIconLayers(ctx, app.Package, adaptiveShape(shape))
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to extract icon for %s: %v\n", app.Name, err)
 		}
@@ -188,7 +430,33 @@ func loadSingleIcon(app config.AppConfig) image.Image {
 		img = graphics.CreatePlaceholder(64, 64)
 	}
 
-	return img
+	return img, adaptive
+}
+
+// adaptiveShape returns the mask used when composing adaptive icon layers.
+// A configured shape is applied to every icon by StandardizeImage,
+// so adaptive icons are then composed unmasked to avoid masking twice.
+func adaptiveShape(shape string) string {
+	if shape != "" {
+		return graphics.ShapeNone
+	}
+	return shape
+}
+
+// themeIcon is the post-processing stage between loading and rendering:
+// it recolors an icon according to the app's icon theme.
+func themeIcon(img image.Image, adaptive *graphics.AdaptiveIcon, shape, theme string, palette []string) image.Image {
+	t, err := graphics.ParseIconTheme(theme, palette)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: invalid icon theme '%s': %v\n", theme, err)
+		return img
+	}
+	if anim, ok := img.(*graphics.AnimatedIcon); ok && t.Active() {
+		return anim.Map(func(frame image.Image) image.Image {
+			return t.Apply(frame, nil, adaptiveShape(shape))
+		})
+	}
+	return t.Apply(img, adaptive, adaptiveShape(shape))
 }
 
 // flashCell provides visual feedback by briefly highlighting the cell border.
@@ -198,52 +466,11 @@ func (m *Model) flashCell(index int) {
 		return
 	}
 
-	cellW, cellH := m.GridCellSize()
-	if cellW <= 0 || cellH <= 0 {
+	output := m.borderOutput(index, m.Config.GetHighlightColor())
+	if output == "" {
 		return
 	}
-
-	col := index % m.Config.Grid.Columns
-	row := index / m.Config.Grid.Columns
-
-	// Calculate top-left position of the cell (1-indexed for ANSI)
-	startX := col*cellW + 1
-	startY := row*cellH + 1
-
-	// Get highlight color from config
-	highlightColor := m.Config.GetHighlightColor()
-	highlight := fmt.Sprintf("\x1b[38;5;%sm", highlightColor)
-	reset := "\x1b[0m"
-
-	// Rounded border characters
-	topLeft := "╭"
-	topRight := "╮"
-	bottomLeft := "╰"
-	bottomRight := "╯"
-	horizontal := "─"
-	vertical := "│"
-
-	var output string
-
-	// Top border
-	output += fmt.Sprintf("\x1b[%d;%dH%s%s", startY, startX, highlight, topLeft)
-	for x := 1; x < cellW-1; x++ {
-		output += horizontal
-	}
-	output += topRight
-
-	// Side borders
-	for y := 1; y < cellH-1; y++ {
-		output += fmt.Sprintf("\x1b[%d;%dH%s", startY+y, startX, vertical)
-		output += fmt.Sprintf("\x1b[%d;%dH%s", startY+y, startX+cellW-1, vertical)
-	}
-
-	// Bottom border
-	output += fmt.Sprintf("\x1b[%d;%dH%s", startY+cellH-1, startX, bottomLeft)
-	for x := 1; x < cellW-1; x++ {
-		output += horizontal
-	}
-	output += bottomRight + reset
+	output += m.badgeOutput(index)
 
 	// Move cursor to bottom
 	output += fmt.Sprintf("\x1b[%d;1H", m.TermHeight)
@@ -260,26 +487,41 @@ func (m *Model) flashCell(index int) {
 
 // drawNormalBorder draws the normal border color for a cell via direct ANSI.
 func (m *Model) drawNormalBorder(index int) {
-	if !m.Config.Style.Border {
+	output := m.borderOutput(index, m.Config.GetBorderColor())
+	if output == "" {
 		return
 	}
+	output += m.badgeOutput(index)
 
-	cellW, cellH := m.GridCellSize()
-	if cellW <= 0 || cellH <= 0 {
-		return
+	output += fmt.Sprintf("\x1b[%d;1H", m.TermHeight)
+	fmt.Fprint(os.Stdout, output)
+}
+
+// borderOutput returns the ANSI sequence drawing a cell's rounded border in the given
+// ANSI 256 color, or "" when borders are disabled.
+func (m *Model) borderOutput(index int, borderColor string) string {
+	if !m.Config.Style.Border {
+		return ""
 	}
+	return boxOutput(m.cellRect(index), borderColor)
+}
 
-	col := index % m.Config.Grid.Columns
-	row := index / m.Config.Grid.Columns
+// boxOutput returns the ANSI sequence drawing a rounded border in the given
+// ANSI 256 color along the edges of r, in terminal cells (0-based).
+func boxOutput(r image.Rectangle, borderColor string) string {
+	cellW, cellH := r.Dx(), r.Dy()
+	if cellW <= 0 || cellH <= 0 {
+		return ""
+	}
 
-	startX := col*cellW + 1
-	startY := row*cellH + 1
+	// Calculate top-left position of the cell (1-indexed for ANSI)
+	startX := r.Min.X + 1
+	startY := r.Min.Y + 1
 
-	// Get normal border color from config
-	borderColor := m.Config.GetBorderColor()
 	color := fmt.Sprintf("\x1b[38;5;%sm", borderColor)
 	reset := "\x1b[0m"
 
+	// Rounded border characters
 	topLeft := "╭"
 	topRight := "╮"
 	bottomLeft := "╰"
@@ -307,8 +549,5 @@ func (m *Model) drawNormalBorder(index int) {
 	for x := 1; x < cellW-1; x++ {
 		output += horizontal
 	}
-	output += bottomRight + reset
-
-	output += fmt.Sprintf("\x1b[%d;1H", m.TermHeight)
-	fmt.Fprint(os.Stdout, output)
+	return output + bottomRight + reset
 }
@@ -2,11 +2,14 @@ package app
 
 import (
 	"fmt"
+	"image"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 
 	"tooie-shelf/internal/graphics"
+	"tooie-shelf/internal/sys"
 )
 
 // ANSI escape codes for cursor positioning and sync output
@@ -39,47 +42,36 @@ func (m Model) View() string {
 	b.WriteString(hideCursor)
 	b.WriteString(cursorHome)
 
-	// Calculate inner dimensions for lipgloss (accounting for border)
-	innerW := cellW
-	innerH := cellH
-	if m.Config.Style.Border {
-		innerW = cellW - 2 // borders take 2 chars horizontally
-		innerH = cellH - 2 // borders take 2 chars vertically
-	}
-	if innerW < 1 {
-		innerW = 1
-	}
-	if innerH < 1 {
-		innerH = 1
+	// First pass: render all borders/frames of the current page, each at its cells
+	start, end := m.pageApps()
+	for i := start; i < end; i++ {
+		r := m.cellRect(i)
+		innerW, innerH := m.frameInnerSize(r)
+		b.WriteString(placeOutput(r, m.renderCellFrame(i, innerW, innerH)))
 	}
-
-	// First pass: render all borders/frames
-	var rows []string
-	appIndex := 0
-
-	for row := 0; row < m.Config.Grid.Rows; row++ {
-		var cells []string
-
-		for col := 0; col < m.Config.Grid.Columns; col++ {
-			var cell string
-			if appIndex < len(m.DisplayApps) {
-				cell = m.renderCellFrame(appIndex, innerW, innerH)
-				appIndex++
-			} else {
-				cell = m.renderEmptyCell(innerW, innerH)
-			}
-			cells = append(cells, cell)
-		}
-
-		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+	for _, r := range m.emptyCells() {
+		innerW, innerH := m.frameInnerSize(r)
+		b.WriteString(placeOutput(r, m.renderEmptyCell(innerW, innerH)))
 	}
 
-	b.WriteString(lipgloss.JoinVertical(lipgloss.Left, rows...))
-
 	// Second pass: overlay sixel images at absolute positions (only if not already drawn)
 	// This ensures sixels are drawn once and persist across renders
 	if !m.SixelsDrawn {
-		m.drawSixelsDirectly(&b)
+		b.WriteString(m.Renderer.ClearAll())
+		// An open folder covers the grid, which is then drawn without images
+		if m.Folder == nil {
+			m.drawWallpaper(&b)
+			// Icons arriving one by one are drawn directly, keeping this output unchanged
+			if m.IconsPending == 0 {
+				m.drawSixelsDirectly(&b)
+			}
+			m.drawFramesOverWallpaper(&b)
+		}
+		m.SixelsDrawn = true
+	}
+	b.WriteString(m.pageIndicator())
+	if m.Folder != nil {
+		b.WriteString(m.Folder.folderOutput())
 	}
 
 	// Move cursor to bottom
@@ -89,8 +81,8 @@ func (m Model) View() string {
 	return b.String()
 }
 
-// drawSixelsDirectly renders sixel images directly to the output.
-// This is called once and the sixels persist in the terminal buffer.
+// drawSixelsDirectly renders icon images directly to the output using the active renderer.
+// This is called once and the images persist in the terminal buffer.
 func (m *Model) drawSixelsDirectly(b *strings.Builder) {
 	// Check if icons are loaded
 	iconsLoaded := false
@@ -106,64 +98,179 @@ func (m *Model) drawSixelsDirectly(b *strings.Builder) {
 	}
 
 	cellW, cellH := m.GridCellSize()
-	iconW, iconH := m.IconCellSize()
 
 	if cellW <= 0 || cellH <= 0 {
 		return
 	}
 
-	appIndex := 0
-	for row := 0; row < m.Config.Grid.Rows && appIndex < len(m.DisplayApps); row++ {
-		for col := 0; col < m.Config.Grid.Columns && appIndex < len(m.DisplayApps); col++ {
-			if appIndex < len(m.Icons) && m.Icons[appIndex] != nil {
-				// Apply icon scale
-				scale := m.GetIconScale(appIndex)
-				scaledIconW := int(float64(iconW) * scale)
-				scaledIconH := int(float64(iconH) * scale)
-				if scaledIconW < 1 {
-					scaledIconW = 1
-				}
-				if scaledIconH < 1 {
-					scaledIconH = 1
-				}
-
-				sixelResult := m.getSixelContentWithDimensions(appIndex, scaledIconW, scaledIconH, scale)
-				if sixelResult.Sixel != "" {
-					// Calculate absolute position for this icon
-					borderOffset := 0
-					if m.Config.Style.Border {
-						borderOffset = 1
-					}
-					padOffset := m.Config.Style.Padding
-
-					// Calculate centering offset based on actual sixel pixel dimensions
-					sixelWidthCells := sixelResult.Width / m.CellPx.Width
-					sixelHeightCells := sixelResult.Height / m.CellPx.Height
-					centerOffsetX := (iconW - sixelWidthCells) / 2
-					centerOffsetY := (iconH - sixelHeightCells) / 2
-
-					// Position: centered within the icon area
-					// +1 because terminal positions are 1-indexed
-					posX := col*cellW + borderOffset + padOffset + centerOffsetX + 1
-					posY := row*cellH + borderOffset + padOffset + centerOffsetY + 1
-
-					if posX < 1 {
-						posX = 1
-					}
-					if posY < 1 {
-						posY = 1
-					}
-
-					// Move cursor and render sixel
-					b.WriteString(fmt.Sprintf(cursorTo, posY, posX))
-					b.WriteString(sixelResult.Sixel)
-				}
-			}
-			appIndex++
+	start, end := m.pageApps()
+	for i := start; i < end; i++ {
+		if i >= len(m.Icons) || m.Icons[i] == nil {
+			continue
+		}
+		scaledIconW, scaledIconH, scale := m.scaledIconCells(i)
+		payload := m.getSixelContentWithDimensions(i, scaledIconW, scaledIconH, scale)
+		if payload.Data == "" {
+			continue
+		}
+		// Move cursor and render image
+		posX, posY := m.iconOrigin(i, payload.Width, payload.Height)
+		b.WriteString(fmt.Sprintf(cursorTo, posY, posX))
+		b.WriteString(payload.Data)
+
+		// Overlay labels are redrawn on top of the image
+		if m.Config.GetLabels() == "overlay" {
+			b.WriteString(m.labelOutput(i))
 		}
 	}
+}
+
+// iconOrigin returns the 1-indexed cell where an icon payload of the given pixel size
+// is drawn: centered within the icon area of the app's cells.
+func (m *Model) iconOrigin(index, pxW, pxH int) (x, y int) {
+	r := m.cellRect(index)
+	iconW, iconH := m.IconCellSize(index)
 
-	m.SixelsDrawn = true
+	borderOffset := 0
+	if m.Config.Style.Border {
+		borderOffset = 1
+	}
+	padOffset := m.Config.Style.Padding
+
+	// Calculate centering offset based on actual image pixel dimensions
+	centerOffsetX := (iconW - pxW/m.CellPx.Width) / 2
+	centerOffsetY := (iconH - pxH/m.CellPx.Height) / 2
+
+	// +1 because terminal positions are 1-indexed
+	x = max(r.Min.X+borderOffset+padOffset+centerOffsetX+1, 1)
+	y = max(r.Min.Y+borderOffset+padOffset+centerOffsetY+1, 1)
+	return x, y
+}
+
+// labelOutput returns the ANSI sequence drawing the app's label at its label row, or "".
+func (m *Model) labelOutput(index int) string {
+	if m.labelRow(index) < 0 || !m.onPage(index) {
+		return ""
+	}
+	r := m.cellRect(index)
+	iconW, _ := m.IconCellSize(index)
+
+	borderOffset := 0
+	if m.Config.Style.Border {
+		borderOffset = 1
+	}
+	labelX := r.Min.X + borderOffset + m.Config.Style.Padding + 1
+	labelY := r.Min.Y + borderOffset + m.labelRow(index) + 1
+	return fmt.Sprintf(cursorTo, labelY, labelX) + cellLabel(m.DisplayApps[index].Name, iconW)
+}
+
+// iconSwapOutput returns the ANSI sequence replacing an icon payload drawn dropRow rows
+// below its origin with another one, used for animation frames and tap bounces.
+func (m *Model) iconSwapOutput(index int, prev graphics.Payload, prevDrop int, next graphics.Payload, nextDrop int) string {
+	var b strings.Builder
+	b.WriteString(m.Renderer.Delete(prev))
+
+	// Transparent pixels don't overwrite the previous payload, so erase its cells first.
+	// Over a wallpaper icons are opaque, and erasing would punch a hole in it.
+	if m.Wallpaper == nil || prevDrop != nextDrop {
+		x, y := m.iconOrigin(index, prev.Width, prev.Height)
+		r := cellBounds(prev, m.CellPx)
+		for row := 0; row < r.Dy(); row++ {
+			fmt.Fprintf(&b, cursorTo+"\x1b[%dX", y+prevDrop+row, x, r.Dx())
+		}
+	}
+
+	x, y := m.iconOrigin(index, next.Width, next.Height)
+	fmt.Fprintf(&b, cursorTo, y+nextDrop, x)
+	b.WriteString(next.Data)
+
+	if m.Config.GetLabels() == "overlay" {
+		b.WriteString(m.labelOutput(index))
+	}
+	b.WriteString(m.badgeOutput(index))
+	return b.String()
+}
+
+// cellBounds returns the cells a payload covers, rounding partial cells up.
+func cellBounds(p graphics.Payload, cellPx sys.CellDim) image.Rectangle {
+	cols := (p.Width + cellPx.Width - 1) / cellPx.Width
+	rows := (p.Height + cellPx.Height - 1) / cellPx.Height
+	return image.Rect(0, 0, cols, rows)
+}
+
+// drawWallpaper draws the background image over the whole grid, before any icon.
+// It covers the frames View wrote, so drawFramesOverWallpaper redraws them afterwards.
+func (m *Model) drawWallpaper(b *strings.Builder) {
+	if m.Wallpaper == nil {
+		return
+	}
+
+	const key = "wallpaper"
+	payload, ok := m.SixelCache[key]
+	if !ok {
+		rows := m.TermHeight - 1
+		diskKey := graphics.PayloadCacheKey(graphics.HashImage(m.Wallpaper.RGBA), m.CellPx, m.TermWidth, rows, 1, graphics.IconStyle{}, m.Renderer)
+		if payload, ok = graphics.LoadCachedPayload(diskKey); !ok {
+			payload = m.Renderer.Render(m.Wallpaper, m.TermWidth, rows, m.CellPx, graphics.IconStyle{})
+			_ = graphics.SaveCachedPayload(diskKey, payload)
+		}
+		m.SixelCache[key] = payload
+	}
+
+	b.WriteString(cursorHome)
+	b.WriteString(payload.Data)
+}
+
+// wallpaperOutput returns the ANSI sequence redrawing the wallpaper under cells r (0-based).
+func (m *Model) wallpaperOutput(r image.Rectangle) string {
+	r = r.Intersect(image.Rect(0, 0, m.TermWidth, m.TermHeight-1))
+	if r.Empty() {
+		return ""
+	}
+	px := image.Rect(r.Min.X*m.CellPx.Width, r.Min.Y*m.CellPx.Height, r.Max.X*m.CellPx.Width, r.Max.Y*m.CellPx.Height)
+	payload := m.Renderer.Render(m.Wallpaper.Crop(px), r.Dx(), r.Dy(), m.CellPx, graphics.IconStyle{})
+	return fmt.Sprintf(cursorTo, r.Min.Y+1, r.Min.X+1) + payload.Data
+}
+
+// drawFramesOverWallpaper redraws borders and labels on top of the wallpaper and icons,
+// so they stay legible. Without a wallpaper the frames from View are never covered.
+func (m *Model) drawFramesOverWallpaper(b *strings.Builder) {
+	if m.Wallpaper == nil {
+		return
+	}
+	color := m.Config.GetBorderColor()
+	start, end := m.pageApps()
+	for i := start; i < end; i++ {
+		b.WriteString(m.borderOutput(i, color))
+		b.WriteString(m.labelOutput(i))
+	}
+	if m.Config.Style.Border {
+		for _, r := range m.emptyCells() {
+			b.WriteString(boxOutput(r, color))
+		}
+	}
+}
+
+// frameInnerSize returns the size lipgloss lays out inside the frame of cells r,
+// accounting for the border.
+func (m *Model) frameInnerSize(r image.Rectangle) (width, height int) {
+	width, height = r.Dx(), r.Dy()
+	if m.Config.Style.Border {
+		width -= 2 // borders take 2 chars horizontally
+		height -= 2
+	}
+	return max(width, 1), max(height, 1)
+}
+
+// placeOutput returns the ANSI sequence drawing a rendered block line by line
+// from the top-left corner of cells r (0-based).
+func placeOutput(r image.Rectangle, block string) string {
+	var b strings.Builder
+	for i, line := range strings.Split(block, "\n") {
+		fmt.Fprintf(&b, cursorTo, r.Min.Y+i+1, r.Min.X+1)
+		b.WriteString(line)
+	}
+	return b.String()
 }
 
 // renderCellFrame renders just the border/frame of a cell.
@@ -189,7 +296,27 @@ func (m *Model) renderCellFrame(index, innerW, innerH int) string {
 			BorderForeground(borderColor)
 	}
 
-	return style.Render("")
+	// Place the label on its row, indented by the padding like the icon
+	content := ""
+	if row := m.labelRow(index); row >= 0 && row < innerH {
+		iconW, _ := m.IconCellSize(index)
+		lines := make([]string, row+1)
+		lines[row] = strings.Repeat(" ", m.Config.Style.Padding) + cellLabel(m.DisplayApps[index].Name, iconW)
+		content = strings.Join(lines, "\n")
+	}
+
+	return style.Render(content)
+}
+
+// cellLabel fits an app name to width terminal columns and centers it.
+// Widths are measured in columns, so wide (e.g. CJK) characters count double.
+func cellLabel(name string, width int) string {
+	if width < 1 {
+		return ""
+	}
+	label := runewidth.Truncate(name, width, "…")
+	pad := (width - runewidth.StringWidth(label)) / 2
+	return strings.Repeat(" ", pad) + label
 }
 
 // renderEmptyCell renders an empty placeholder cell.
@@ -207,19 +334,72 @@ func (m *Model) renderEmptyCell(innerW, innerH int) string {
 	return style.Render("")
 }
 
-// getSixelContentWithDimensions retrieves cached sixel with dimensions or generates new.
-func (m *Model) getSixelContentWithDimensions(index, widthCells, heightCells int, scale float64) graphics.SixelResult {
+// getSixelContentWithDimensions retrieves a cached payload with dimensions or renders a new one.
+func (m *Model) getSixelContentWithDimensions(index, widthCells, heightCells int, scale float64) graphics.Payload {
 	key := fmt.Sprintf("%d_%d_%d_%.2f", index, widthCells, heightCells, scale)
 
 	if cached, ok := m.SixelCache[key]; ok {
 		return cached
 	}
 
-	var result graphics.SixelResult
+	var result graphics.Payload
 	if index < len(m.Icons) && m.Icons[index] != nil {
-		result = graphics.RenderSixelWithDimensions(m.Icons[index], widthCells, heightCells, m.CellPx)
+		source := ""
+		if index < len(m.IconSources) {
+			source = m.IconSources[index]
+		}
+		style := m.payloadStyle(index, widthCells, heightCells)
+		result = renderCached(m.Renderer, m.Icons[index], source, m.CellPx, widthCells, heightCells, scale, style)
 	}
 
 	m.SixelCache[key] = result
 	return result
 }
+
+// scaledIconCells returns the cells an app's icon is rendered into after its icon scale.
+func (m *Model) scaledIconCells(index int) (widthCells, heightCells int, scale float64) {
+	iconW, iconH := m.IconCellSize(index)
+	scale = m.GetIconScale(index)
+	widthCells = max(int(float64(iconW)*scale), 1)
+	heightCells = max(int(float64(iconH)*scale), 1)
+	return widthCells, heightCells, scale
+}
+
+// payloadStyle returns the full render style of an app's icon, including its wallpaper backdrop.
+func (m *Model) payloadStyle(index, widthCells, heightCells int) graphics.IconStyle {
+	style := m.iconStyle(index)
+	if m.Wallpaper != nil {
+		// Edges blend into the wallpaper rather than the terminal background
+		style.Backdrop = m.iconBackdrop(index, widthCells, heightCells)
+		style.Matte = nil
+	}
+	return style
+}
+
+// renderCached renders an image, trying the on-disk payload cache first when its source
+// identity (see iconSource) is known. A cache hit never reads the image's pixels.
+func renderCached(r graphics.Renderer, img image.Image, source string, cellPx sys.CellDim, widthCells, heightCells int, scale float64, style graphics.IconStyle) graphics.Payload {
+	diskKey := ""
+	if source != "" {
+		diskKey = graphics.PayloadCacheKey(source, cellPx, widthCells, heightCells, scale, style, r)
+		if cached, ok := graphics.LoadCachedPayload(diskKey); ok {
+			return cached
+		}
+	}
+
+	result := r.Render(img, widthCells, heightCells, cellPx, style)
+	if diskKey != "" {
+		_ = graphics.SaveCachedPayload(diskKey, result)
+	}
+	return result
+}
+
+// iconBackdrop returns the wallpaper pixels an icon rendered into the given cells will cover.
+// Icons are prepared as squares as large as fit, positioned by iconOrigin.
+func (m *Model) iconBackdrop(index, widthCells, heightCells int) image.Image {
+	side := min(widthCells*m.CellPx.Width, heightCells*m.CellPx.Height)
+	x, y := m.iconOrigin(index, side, side)
+	px := (x - 1) * m.CellPx.Width
+	py := (y - 1) * m.CellPx.Height
+	return m.Wallpaper.Backdrop(image.Rect(px, py, px+side, py+side))
+}
@@ -1,23 +1,72 @@
 package config
 
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
 // Config represents the launcher configuration.
 type Config struct {
-	Display  []string       `yaml:"display,omitempty"`  // App names in display order (if empty, show all)
-	Grid     GridConfig     `yaml:"grid"`
-	Style    StyleConfig    `yaml:"style"`
-	Behavior BehaviorConfig `yaml:"behavior"`
-	Apps     []AppConfig    `yaml:"apps"`
+	Display  []string        `yaml:"display,omitempty"` // App names in display order (if empty, show all)
+	Grid     GridConfig      `yaml:"grid"`
+	Style    StyleConfig     `yaml:"style"`
+	Behavior BehaviorConfig  `yaml:"behavior"`
+	Apps     []AppConfig     `yaml:"apps"`
+	Profiles []ProfileConfig `yaml:"profiles,omitempty"` // Layouts picked by terminal size; the first match wins
+
+	LightTerminal bool `yaml:"-"` // Terminal reported a light background (OSC 11); picks default colors
 }
 
 // BehaviorConfig defines behavior options.
 type BehaviorConfig struct {
 	CloseOnLaunch bool `yaml:"close_on_launch"`
+	BadgeInterval int  `yaml:"badge_interval,omitempty"` // Seconds between notification badge polls, default 30
+	IconTimeout   int  `yaml:"icon_timeout,omitempty"`   // Seconds each icon source may take before the next is tried, default 10
+	AnimateIcons  bool `yaml:"animate_icons"`            // Play animated GIF/WebP icons (false shows the first frame)
+	Animation     bool `yaml:"animation"`                // Bounce icons when tapped
+
+	Resize ResizeConfig `yaml:"resize,omitempty"` // When terminal resizes lay the grid out again
+}
+
+// ResizeConfig tells soft keyboard resizes, which are ignored, from genuine ones.
+type ResizeConfig struct {
+	DebounceMs        int     `yaml:"debounce_ms,omitempty"`         // Milliseconds a new size must hold before it is acted on, default 250
+	KeyboardMinShrink float64 `yaml:"keyboard_min_shrink,omitempty"` // Smallest height-only shrink (share of rows) taken for the keyboard, default 0.2
+	KeyboardMaxShrink float64 `yaml:"keyboard_max_shrink,omitempty"` // Largest height-only shrink taken for the keyboard, default 0.7
 }
 
 // GridConfig defines the grid layout.
 type GridConfig struct {
-	Rows    int `yaml:"rows"`
-	Columns int `yaml:"columns"`
+	Rows    GridSize `yaml:"rows"`    // Number of rows, or "auto"
+	Columns GridSize `yaml:"columns"` // Number of columns, or "auto"
+}
+
+// GridAuto sizes a grid dimension from the terminal's geometry and the number of apps.
+const GridAuto GridSize = -1
+
+// GridSize is a number of grid rows or columns, or GridAuto.
+type GridSize int
+
+// UnmarshalYAML accepts a number or "auto". Negative numbers are rejected rather than
+// read as GridAuto.
+func (s *GridSize) UnmarshalYAML(value *yaml.Node) error {
+	if value.Value == "auto" {
+		*s = GridAuto
+		return nil
+	}
+	var n int
+	if err := value.Decode(&n); err != nil || n < 0 {
+		return fmt.Errorf("grid size must be a positive number or \"auto\" (got %q)", value.Value)
+	}
+	*s = GridSize(n)
+	return nil
+}
+
+// IsAuto reports whether the dimension is sized automatically.
+func (s GridSize) IsAuto() bool {
+	return s == GridAuto
 }
 
 // StyleConfig defines visual styling options.
@@ -27,16 +76,45 @@ type StyleConfig struct {
 	IconScale       float64 `yaml:"icon_scale,omitempty"` // Global icon scale (0.1-1.0), default 1.0
 	BorderColor     string `yaml:"border_color,omitempty"`     // Normal border color (ANSI 256 color or "default")
 	HighlightColor  string `yaml:"highlight_color,omitempty"`  // Click highlight color (ANSI 256 color or "default")
+	Graphics        string `yaml:"graphics,omitempty"`         // Graphics protocol: "auto", "sixel", "kitty", "iterm", "blocks" or "braille"
+	Sixel           SixelConfig `yaml:"sixel,omitempty"`       // Sixel encoder options
+	IconShape       string `yaml:"icon_shape,omitempty"`       // Icon mask: none, circle, squircle, rounded-square, square, teardrop
+	IconBackground  string `yaml:"icon_background,omitempty"`  // Fill inside the icon shape ("#rrggbb"), empty for none
+	IconTheme       string   `yaml:"icon_theme,omitempty"`     // Icon recolor: none, grayscale, tint:<color>, duotone, monochrome-layer
+	IconPalette     []string `yaml:"icon_palette,omitempty"`   // Theme colors: [dark, light] ("#rrggbb")
+	Labels          string `yaml:"labels,omitempty"`           // App name labels: "none", "below" or "overlay"
+	IconPack        string `yaml:"icon_pack,omitempty"`        // Package of an installed ADW/Nova-compatible icon pack
+	Background      string `yaml:"background,omitempty"`       // Wallpaper image drawn behind the grid (path or URL)
+	Badges          string `yaml:"badges,omitempty"`           // Notification badges: "none", "count" or "dot"
+	BadgeColor      string `yaml:"badge_color,omitempty"`      // Badge background color (ANSI 256 color or "default")
+}
+
+// SixelConfig defines options for the built-in sixel encoder.
+type SixelConfig struct {
+	Colors    int    `yaml:"colors,omitempty"`    // Palette size (2-256); 0 uses the terminal's register count
+	Quantizer string `yaml:"quantizer,omitempty"` // "median-cut" (default) or "octree"
+	Dither    string `yaml:"dither,omitempty"`    // "floyd-steinberg" (default), "ordered" or "none"
 }
 
 // AppConfig defines a single app entry.
 type AppConfig struct {
-	Name      string  `yaml:"name"`
-	Icon      string  `yaml:"icon"`
-	Package   string  `yaml:"package,omitempty"`           // Android package name
-	Activity  string  `yaml:"activity,omitempty"`          // Android activity
-	Command   string  `yaml:"command,omitempty"`           // Linux command/script/binary (takes priority over package)
-	IconScale float64 `yaml:"icon_scale,omitempty"`        // Per-app override (0.1-1.0)
+	Name           string      `yaml:"name"`
+	Icon           string      `yaml:"icon"`
+	Package        string      `yaml:"package,omitempty"`         // Android package name
+	Activity       string      `yaml:"activity,omitempty"`        // Android activity
+	Command        string      `yaml:"command,omitempty"`         // Linux command/script/binary (takes priority over package)
+	IconScale      float64     `yaml:"icon_scale,omitempty"`      // Per-app override (0.1-1.0)
+	IconShape      string      `yaml:"icon_shape,omitempty"`      // Per-app icon mask override
+	IconBackground string      `yaml:"icon_background,omitempty"` // Per-app icon fill override ("#rrggbb")
+	IconTheme      string      `yaml:"icon_theme,omitempty"`      // Per-app icon theme override ("none" to opt out)
+	Folder         []AppConfig `yaml:"folder,omitempty"`          // Child apps; makes this entry a folder that opens them
+	Span           SpanConfig  `yaml:"span,omitempty"`            // Grid cells covered (default 1x1)
+}
+
+// SpanConfig is the block of grid cells an app covers.
+type SpanConfig struct {
+	Rows int `yaml:"rows,omitempty"`
+	Cols int `yaml:"cols,omitempty"`
 }
 
 // IsCommand returns true if this app runs a command instead of launching an Android app.
@@ -44,6 +122,16 @@ func (a *AppConfig) IsCommand() bool {
 	return a.Command != ""
 }
 
+// IsFolder returns true if this entry is a folder of other apps.
+func (a *AppConfig) IsFolder() bool {
+	return len(a.Folder) > 0
+}
+
+// GetSpan returns the grid rows and columns an app covers, 1 for each unset dimension.
+func (a *AppConfig) GetSpan() (rows, cols int) {
+	return max(a.Span.Rows, 1), max(a.Span.Cols, 1)
+}
+
 // GetIconScale returns the effective icon scale for an app (per-app or global).
 func (c *Config) GetIconScale(app AppConfig) float64 {
 	if app.IconScale > 0 {
@@ -55,10 +143,51 @@ func (c *Config) GetIconScale(app AppConfig) float64 {
 	return 1.0
 }
 
+// GetIconShape returns the effective icon mask shape for an app (per-app or global).
+// Empty means no mask; adaptive icons then use their default circle.
+func (c *Config) GetIconShape(app AppConfig) string {
+	if app.IconShape != "" {
+		return app.IconShape
+	}
+	return c.Style.IconShape
+}
+
+// GetIconBackground returns the effective icon fill color for an app (per-app or global).
+func (c *Config) GetIconBackground(app AppConfig) string {
+	if app.IconBackground != "" {
+		return app.IconBackground
+	}
+	return c.Style.IconBackground
+}
+
+// GetIconTheme returns the effective icon theme for an app (per-app or global).
+func (c *Config) GetIconTheme(app AppConfig) string {
+	if app.IconTheme != "" {
+		return app.IconTheme
+	}
+	return c.Style.IconTheme
+}
+
+// PageBreak is a display entry that starts a new page.
+const PageBreak = "---"
+
 // GetDisplayApps returns apps in display order. If Display is empty, returns all apps.
 func (c *Config) GetDisplayApps() []AppConfig {
+	var result []AppConfig
+	for _, section := range c.GetDisplaySections() {
+		result = append(result, section...)
+	}
+	return result
+}
+
+// GetDisplaySections returns apps in display order, split at page breaks.
+// Empty sections are dropped. If Display is empty, all apps form one section.
+func (c *Config) GetDisplaySections() [][]AppConfig {
 	if len(c.Display) == 0 {
-		return c.Apps
+		if len(c.Apps) == 0 {
+			return nil
+		}
+		return [][]AppConfig{c.Apps}
 	}
 
 	// Build a map of apps by name
@@ -68,13 +197,24 @@ func (c *Config) GetDisplayApps() []AppConfig {
 	}
 
 	// Return apps in display order
-	var result []AppConfig
+	var sections [][]AppConfig
+	var section []AppConfig
 	for _, name := range c.Display {
+		if name == PageBreak {
+			if len(section) > 0 {
+				sections = append(sections, section)
+			}
+			section = nil
+			continue
+		}
 		if app, ok := appMap[name]; ok {
-			result = append(result, app)
+			section = append(section, app)
 		}
 	}
-	return result
+	if len(section) > 0 {
+		sections = append(sections, section)
+	}
+	return sections
 }
 
 // clampScale ensures scale is within valid range.
@@ -100,28 +240,104 @@ func DefaultConfig() Config {
 			Border:         true,
 			Padding:        1,
 			IconScale:      1.0,
-			BorderColor:    "240",
-			HighlightColor: "96",
+			Graphics:       "auto",
 		},
 		Behavior: BehaviorConfig{
 			CloseOnLaunch: false,
+			AnimateIcons:  true,
+			Animation:     true,
 		},
 		Apps: []AppConfig{},
 	}
 }
 
-// GetBorderColor returns the border color, or default if not set.
+// GetBorderColor returns the border color, or a default suited to the terminal background if not set.
 func (c *Config) GetBorderColor() string {
 	if c.Style.BorderColor == "" || c.Style.BorderColor == "default" {
+		if c.LightTerminal {
+			return "250"
+		}
 		return "240"
 	}
 	return c.Style.BorderColor
 }
 
-// GetHighlightColor returns the highlight color, or default if not set.
+// GetHighlightColor returns the highlight color, or a default suited to the terminal background if not set.
 func (c *Config) GetHighlightColor() string {
 	if c.Style.HighlightColor == "" || c.Style.HighlightColor == "default" {
+		if c.LightTerminal {
+			return "90"
+		}
 		return "96"
 	}
 	return c.Style.HighlightColor
 }
+
+// GetGraphics returns the configured graphics protocol, or "auto" if not set.
+func (c *Config) GetGraphics() string {
+	if c.Style.Graphics == "" {
+		return "auto"
+	}
+	return c.Style.Graphics
+}
+
+// GetBadges returns the notification badge style, or "none" if not set.
+func (c *Config) GetBadges() string {
+	if c.Style.Badges == "" {
+		return "none"
+	}
+	return c.Style.Badges
+}
+
+// GetBadgeColor returns the badge background color, or default if not set.
+func (c *Config) GetBadgeColor() string {
+	if c.Style.BadgeColor == "" || c.Style.BadgeColor == "default" {
+		return "160"
+	}
+	return c.Style.BadgeColor
+}
+
+// GetBadgeInterval returns the notification polling interval, or 30s if not set.
+func (c *Config) GetBadgeInterval() time.Duration {
+	if c.Behavior.BadgeInterval <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.Behavior.BadgeInterval) * time.Second
+}
+
+// GetIconTimeout returns how long one icon source may take, or 10s if not set.
+func (c *Config) GetIconTimeout() time.Duration {
+	if c.Behavior.IconTimeout <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(c.Behavior.IconTimeout) * time.Second
+}
+
+// GetResizeDebounce returns how long a new terminal size must hold, or 250ms if not set.
+func (c *Config) GetResizeDebounce() time.Duration {
+	if c.Behavior.Resize.DebounceMs <= 0 {
+		return 250 * time.Millisecond
+	}
+	return time.Duration(c.Behavior.Resize.DebounceMs) * time.Millisecond
+}
+
+// GetKeyboardShrink returns the range of height-only shrinks, as a share of the rows,
+// taken for the soft keyboard opening, or 0.2 to 0.7 if not set.
+func (c *Config) GetKeyboardShrink() (lo, hi float64) {
+	lo, hi = c.Behavior.Resize.KeyboardMinShrink, c.Behavior.Resize.KeyboardMaxShrink
+	if lo <= 0 {
+		lo = 0.2
+	}
+	if hi <= 0 {
+		hi = 0.7
+	}
+	return lo, hi
+}
+
+// GetLabels returns the label placement, or "none" if not set.
+func (c *Config) GetLabels() string {
+	if c.Style.Labels == "" {
+		return "none"
+	}
+	return c.Style.Labels
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -36,17 +37,10 @@ func Load(path string) (Config, error) {
 		return cfg, fmt.Errorf("failed to parse config: %w", err)
 	}
 
-	// Expand ~ in icon paths and auto-detect missing package/activity
-	for i := range cfg.Apps {
-		cfg.Apps[i].Icon = expandPath(cfg.Apps[i].Icon)
+	cfg.Style.Background = expandPath(cfg.Style.Background)
 
-		// Auto-detect package and activity if not specified and not a command
-		if cfg.Apps[i].Command == "" && (cfg.Apps[i].Package == "" || cfg.Apps[i].Activity == "") {
-			if err := autoDetectAppInfo(&cfg.Apps[i]); err != nil {
-				// Log warning but don't fail - app may be optional
-				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
-			}
-		}
+	for i := range cfg.Apps {
+		prepareApp(&cfg.Apps[i])
 	}
 
 	// Validate configuration
@@ -57,6 +51,27 @@ func Load(path string) (Config, error) {
 	return cfg, nil
 }
 
+// prepareApp expands ~ in an app's icon path and auto-detects a missing package/activity.
+// Folders are prepared child by child.
+func prepareApp(app *AppConfig) {
+	app.Icon = expandPath(app.Icon)
+
+	if app.IsFolder() {
+		for i := range app.Folder {
+			prepareApp(&app.Folder[i])
+		}
+		return
+	}
+
+	// Auto-detect package and activity if not specified and not a command
+	if app.Command == "" && (app.Package == "" || app.Activity == "") {
+		if err := autoDetectAppInfo(app); err != nil {
+			// Log warning but don't fail - app may be optional
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+}
+
 // autoDetectAppInfo attempts to detect package and activity for an app.
 func autoDetectAppInfo(app *AppConfig) error {
 	// Skip if already has both package and activity
@@ -106,42 +121,187 @@ func expandPath(path string) string {
 	return path
 }
 
+// validateSpan checks an app's span. Spans larger than the grid are clamped to it.
+func validateSpan(app AppConfig) error {
+	if app.Span.Rows < 0 || app.Span.Cols < 0 {
+		return fmt.Errorf("app %q: span rows and cols must be positive", app.Name)
+	}
+	return nil
+}
+
 // validate checks the configuration for errors.
 func validate(cfg Config) error {
-	if cfg.Grid.Rows < 1 {
-		return fmt.Errorf("grid.rows must be at least 1")
+	if cfg.Grid.Rows < 1 && !cfg.Grid.Rows.IsAuto() {
+		return fmt.Errorf("grid.rows must be at least 1 or auto")
 	}
-	if cfg.Grid.Columns < 1 {
-		return fmt.Errorf("grid.columns must be at least 1")
+	if cfg.Grid.Columns < 1 && !cfg.Grid.Columns.IsAuto() {
+		return fmt.Errorf("grid.columns must be at least 1 or auto")
 	}
 
-	for i, app := range cfg.Apps {
-		// Android apps require both package and activity
-		if app.Command == "" {
-			if app.Package == "" {
-				return fmt.Errorf("app %d (%s): package name is required for Android apps (or use auto-detect by omitting package/activity)", i, app.Name)
+	for _, app := range cfg.Apps {
+		if err := validateSpan(app); err != nil {
+			return err
+		}
+		for _, child := range app.Folder {
+			if child.IsFolder() {
+				return fmt.Errorf("folder %q: folders cannot contain folders (%q)", app.Name, child.Name)
 			}
-			if app.Activity == "" {
-				return fmt.Errorf("app %d (%s): activity is required for Android apps (or use auto-detect by omitting package/activity)", i, app.Name)
+			if err := validateSpan(child); err != nil {
+				return err
 			}
 		}
-		if app.Icon != "" {
-			// Skip file validation for special icon sources
-			isSpecialSource := strings.HasPrefix(app.Icon, "dashboard:") ||
-				strings.HasPrefix(app.Icon, "http://") ||
-				strings.HasPrefix(app.Icon, "https://")
-
-			if !isSpecialSource {
-				if _, err := os.Stat(app.Icon); err != nil {
-					return fmt.Errorf("app %d (%s): icon file not found: %s", i, app.Name, app.Icon)
-				}
+	}
+
+	switch cfg.GetGraphics() {
+	case "auto", "sixel", "kitty", "iterm", "blocks", "braille":
+	default:
+		return fmt.Errorf("style.graphics must be one of auto, sixel, kitty, iterm, blocks, braille (got %q)", cfg.Style.Graphics)
+	}
+
+	switch cfg.GetLabels() {
+	case "none", "below", "overlay":
+	default:
+		return fmt.Errorf("style.labels must be one of none, below, overlay (got %q)", cfg.Style.Labels)
+	}
+
+	switch cfg.GetBadges() {
+	case "none", "count", "dot":
+	default:
+		return fmt.Errorf("style.badges must be one of none, count, dot (got %q)", cfg.Style.Badges)
+	}
+	if cfg.Behavior.BadgeInterval < 0 {
+		return fmt.Errorf("behavior.badge_interval must not be negative")
+	}
+	if cfg.Behavior.IconTimeout < 0 {
+		return fmt.Errorf("behavior.icon_timeout must not be negative")
+	}
+	if cfg.Behavior.Resize.DebounceMs < 0 {
+		return fmt.Errorf("behavior.resize.debounce_ms must not be negative")
+	}
+	if r := cfg.Behavior.Resize; r.KeyboardMinShrink < 0 || r.KeyboardMaxShrink < 0 || r.KeyboardMaxShrink > 1 {
+		return fmt.Errorf("behavior.resize.keyboard_min_shrink and keyboard_max_shrink must be between 0 and 1")
+	}
+	if lo, hi := cfg.GetKeyboardShrink(); lo > hi {
+		return fmt.Errorf("behavior.resize.keyboard_min_shrink must not exceed keyboard_max_shrink")
+	}
+
+	if cfg.Style.Sixel.Colors != 0 && (cfg.Style.Sixel.Colors < 2 || cfg.Style.Sixel.Colors > 256) {
+		return fmt.Errorf("style.sixel.colors must be between 2 and 256")
+	}
+	switch cfg.Style.Sixel.Quantizer {
+	case "", "median-cut", "octree":
+	default:
+		return fmt.Errorf("style.sixel.quantizer must be median-cut or octree (got %q)", cfg.Style.Sixel.Quantizer)
+	}
+	if err := validateIconShape("style.icon_shape", cfg.Style.IconShape); err != nil {
+		return err
+	}
+	if err := validateIconBackground("style.icon_background", cfg.Style.IconBackground); err != nil {
+		return err
+	}
+	if err := validateIconTheme("style.icon_theme", cfg.Style.IconTheme); err != nil {
+		return err
+	}
+	if len(cfg.Style.IconPalette) > 2 {
+		return fmt.Errorf("style.icon_palette takes at most 2 colors (dark, light), got %d", len(cfg.Style.IconPalette))
+	}
+	for _, c := range cfg.Style.IconPalette {
+		if !hexColorRe.MatchString(c) {
+			return fmt.Errorf("style.icon_palette colors must be hex colors like \"#rrggbb\" (got %q)", c)
+		}
+	}
+	switch cfg.Style.Sixel.Dither {
+	case "", "floyd-steinberg", "ordered", "none":
+	default:
+		return fmt.Errorf("style.sixel.dither must be floyd-steinberg, ordered or none (got %q)", cfg.Style.Sixel.Dither)
+	}
+
+	for i, app := range cfg.Apps {
+		field := fmt.Sprintf("app %d (%s)", i, app.Name)
+		if err := validateApp(field, app); err != nil {
+			return err
+		}
+		for j, child := range app.Folder {
+			if err := validateApp(fmt.Sprintf("%s: folder app %d (%s)", field, j, child.Name), child); err != nil {
+				return err
+			}
+		}
+	}
+
+	return validateProfiles(cfg)
+}
+
+// validateApp checks one app or folder entry; field names it in errors.
+// Folders launch nothing, so they need no package or activity.
+func validateApp(field string, app AppConfig) error {
+	// Android apps require both package and activity
+	if app.Command == "" && !app.IsFolder() {
+		if app.Package == "" {
+			return fmt.Errorf("%s: package name is required for Android apps (or use auto-detect by omitting package/activity)", field)
+		}
+		if app.Activity == "" {
+			return fmt.Errorf("%s: activity is required for Android apps (or use auto-detect by omitting package/activity)", field)
+		}
+	}
+	if err := validateIconShape(field+": icon_shape", app.IconShape); err != nil {
+		return err
+	}
+	if err := validateIconBackground(field+": icon_background", app.IconBackground); err != nil {
+		return err
+	}
+	if err := validateIconTheme(field+": icon_theme", app.IconTheme); err != nil {
+		return err
+	}
+	if app.Icon != "" {
+		// Skip file validation for special icon sources
+		isSpecialSource := strings.HasPrefix(app.Icon, "dashboard:") ||
+			strings.HasPrefix(app.Icon, "http://") ||
+			strings.HasPrefix(app.Icon, "https://")
+
+		if !isSpecialSource {
+			if _, err := os.Stat(app.Icon); err != nil {
+				return fmt.Errorf("%s: icon file not found: %s", field, app.Icon)
 			}
 		}
 	}
+	return nil
+}
+
+// validateIconShape checks an icon_shape value.
+func validateIconShape(field, shape string) error {
+	switch shape {
+	case "", "none", "circle", "squircle", "rounded-square", "square", "teardrop":
+		return nil
+	}
+	return fmt.Errorf("%s must be none, circle, squircle, rounded-square, square or teardrop (got %q)", field, shape)
+}
 
+// validateIconBackground checks an icon_background value.
+func validateIconBackground(field, background string) error {
+	if background != "" && !hexColorRe.MatchString(background) {
+		return fmt.Errorf("%s must be a hex color like \"#rrggbb\" (got %q)", field, background)
+	}
 	return nil
 }
 
+// validateIconTheme checks an icon_theme value.
+func validateIconTheme(field, theme string) error {
+	switch theme {
+	case "", "none", "grayscale", "duotone", "monochrome-layer":
+		return nil
+	}
+	if tint, ok := strings.CutPrefix(theme, "tint:"); ok {
+		if !hexColorRe.MatchString(tint) {
+			return fmt.Errorf("%s tint must be a hex color like \"tint:#rrggbb\" (got %q)", field, theme)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s must be none, grayscale, tint:<color>, duotone or monochrome-layer (got %q)", field, theme)
+}
+
+// hexColorRe matches "#rrggbb" and "#rrggbbaa" colors.
+var hexColorRe = regexp.MustCompile(`^#([0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
 // EnsureConfigDir creates the config directory if it doesn't exist.
 func EnsureConfigDir() error {
 	home, err := os.UserHomeDir()
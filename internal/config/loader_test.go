@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadYAML loads a config file holding data.
+func loadYAML(t *testing.T, data string) (Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+func TestLoadFolder(t *testing.T) {
+	cfg, err := loadYAML(t, `
+apps:
+  - name: Dev
+    icon: "dashboard:folder"
+    folder:
+      - name: Shell
+        command: bash
+      - name: Maps
+        package: com.google.android.apps.maps
+        activity: com.google.android.maps.MapsActivity
+        icon_theme: grayscale
+`)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cfg.Apps) != 1 || !cfg.Apps[0].IsFolder() || len(cfg.Apps[0].Folder) != 2 {
+		t.Fatalf("apps = %+v, want one folder of 2 apps", cfg.Apps)
+	}
+}
+
+func TestLoadFolderChildInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		child string
+		want  string
+	}{
+		{"shape", "icon_shape: hexagon", "app 0 (Dev): folder app 0 (Shell): icon_shape"},
+		{"background", `icon_background: "red"`, "app 0 (Dev): folder app 0 (Shell): icon_background"},
+		{"theme", "icon_theme: sepia", "app 0 (Dev): folder app 0 (Shell): icon_theme"},
+		{"icon file", "icon: /nonexistent/shell.png", "app 0 (Dev): folder app 0 (Shell): icon file not found"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadYAML(t, `
+apps:
+  - name: Dev
+    folder:
+      - name: Shell
+        command: bash
+        `+tt.child+`
+`)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileConfig is a layout used while the terminal's size meets its conditions,
+// such as one for portrait and one for landscape.
+type ProfileConfig struct {
+	Name    string      `yaml:"name"`
+	When    ProfileWhen `yaml:"when"`
+	Display []string    `yaml:"display,omitempty"` // Replaces the top-level display when set
+	Grid    yaml.Node   `yaml:"grid,omitempty"`    // Overrides top-level grid fields
+	Style   yaml.Node   `yaml:"style,omitempty"`   // Overrides top-level style fields
+}
+
+// ProfileWhen holds the terminal sizes a profile applies to. Zero bounds are unset.
+type ProfileWhen struct {
+	MinCols   int     `yaml:"min_cols,omitempty"`
+	MaxCols   int     `yaml:"max_cols,omitempty"`
+	MinRows   int     `yaml:"min_rows,omitempty"`
+	MaxRows   int     `yaml:"max_rows,omitempty"`
+	MinAspect float64 `yaml:"min_aspect,omitempty"` // Columns per row
+	MaxAspect float64 `yaml:"max_aspect,omitempty"` // Columns per row
+}
+
+// Matches reports whether a terminal of cols x rows cells meets every set bound.
+func (w ProfileWhen) Matches(cols, rows int) bool {
+	if rows <= 0 {
+		return false
+	}
+	aspect := float64(cols) / float64(rows)
+	switch {
+	case w.MinCols > 0 && cols < w.MinCols, w.MaxCols > 0 && cols > w.MaxCols:
+		return false
+	case w.MinRows > 0 && rows < w.MinRows, w.MaxRows > 0 && rows > w.MaxRows:
+		return false
+	case w.MinAspect > 0 && aspect < w.MinAspect, w.MaxAspect > 0 && aspect > w.MaxAspect:
+		return false
+	}
+	return true
+}
+
+// ProfileFor returns the index of the first profile matching a terminal of cols x rows
+// cells, or -1 when none does and the top-level layout applies.
+func (c *Config) ProfileFor(cols, rows int) int {
+	for i, p := range c.Profiles {
+		if p.When.Matches(cols, rows) {
+			return i
+		}
+	}
+	return -1
+}
+
+// WithProfile returns the configuration with profile i applied, or unchanged for -1.
+// The profile's grid and style fields override the top-level ones, keeping the fields
+// it leaves unset, and its display replaces the top-level display when set.
+func (c Config) WithProfile(i int) (Config, error) {
+	if i < 0 || i >= len(c.Profiles) {
+		return c, nil
+	}
+	p := c.Profiles[i]
+	if len(p.Display) > 0 {
+		c.Display = p.Display
+	}
+	if !p.Grid.IsZero() {
+		if err := p.Grid.Decode(&c.Grid); err != nil {
+			return c, fmt.Errorf("grid: %w", err)
+		}
+	}
+	if !p.Style.IsZero() {
+		if err := p.Style.Decode(&c.Style); err != nil {
+			return c, fmt.Errorf("style: %w", err)
+		}
+		c.Style.Background = expandPath(c.Style.Background)
+	}
+	return c, nil
+}
+
+// validateProfiles checks every layout profile's conditions and the configuration it produces.
+func validateProfiles(cfg Config) error {
+	for i, p := range cfg.Profiles {
+		w := p.When
+		if w.MinCols < 0 || w.MaxCols < 0 || w.MinRows < 0 || w.MaxRows < 0 || w.MinAspect < 0 || w.MaxAspect < 0 {
+			return fmt.Errorf("profile %q: when bounds must be positive", p.Name)
+		}
+		if (w.MaxCols > 0 && w.MinCols > w.MaxCols) || (w.MaxRows > 0 && w.MinRows > w.MaxRows) || (w.MaxAspect > 0 && w.MinAspect > w.MaxAspect) {
+			return fmt.Errorf("profile %q: when minimum exceeds maximum", p.Name)
+		}
+
+		applied, err := cfg.WithProfile(i)
+		if err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+		applied.Profiles = nil
+		if err := validate(applied); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package graphics
+
+import (
+	"archive/zip"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"path"
+	"strings"
+)
+
+// Adaptive icon layers are 108dp squares whose visible area is the central 72dp.
+const (
+	adaptiveLayerDp    = 108
+	adaptiveViewportDp = 72
+	adaptiveMinSize    = 192
+)
+
+// AdaptiveIcon holds the layers of an Android adaptive icon.
+type AdaptiveIcon struct {
+	Background image.Image
+	Foreground image.Image
+	Monochrome image.Image // nil if the app doesn't ship a monochrome layer
+}
+
+// Compose stacks the foreground over the background, crops the 72dp viewport and masks it to shape.
+// An empty shape defaults to a circle, like stock Android launchers; ShapeNone leaves the viewport square.
+func (a *AdaptiveIcon) Compose(shape string) image.Image {
+	if shape == "" {
+		shape = ShapeCircle
+	}
+	return ApplyShapeMask(a.composeLayers(a.Background, a.Foreground), shape)
+}
+
+// composeLayers draws the given layers over each other and crops to the visible viewport.
+func (a *AdaptiveIcon) composeLayers(layers ...image.Image) image.Image {
+	// Work at the resolution of the largest layer
+	layerSize := 0
+	for _, l := range layers {
+		if l == nil {
+			continue
+		}
+		if d := l.Bounds().Dx(); d > layerSize {
+			layerSize = d
+		}
+		if d := l.Bounds().Dy(); d > layerSize {
+			layerSize = d
+		}
+	}
+	size := layerSize * adaptiveViewportDp / adaptiveLayerDp
+	if size < adaptiveMinSize {
+		size = adaptiveMinSize
+		layerSize = size * adaptiveLayerDp / adaptiveViewportDp
+	}
+
+	full := image.NewRGBA(image.Rect(0, 0, layerSize, layerSize))
+	for _, l := range layers {
+		if l == nil {
+			continue
+		}
+		scaled := ScaleImage(l, layerSize, layerSize)
+		draw.Draw(full, full.Bounds(), scaled, image.Point{}, draw.Over)
+	}
+
+	inset := (layerSize - size) / 2
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(dst, dst.Bounds(), full, image.Point{X: inset, Y: inset}, draw.Src)
+	return dst
+}
+
+// apkFiles is a merged view of the entries of an app's APKs (base + splits).
+// Density-specific layer bitmaps often live in split APKs while the XML is in the base.
+type apkFiles struct {
+	files   map[string]*zip.File
+	readers []*zip.ReadCloser
+	tables  []*ResTable // resources.arsc of every APK, loaded on first lookup
+	loaded  bool
+}
+
+// openAPKFiles opens all APKs; entries from earlier paths take priority.
+func openAPKFiles(apkPaths []string) (*apkFiles, error) {
+	a := &apkFiles{files: make(map[string]*zip.File)}
+	for _, p := range apkPaths {
+		r, err := zip.OpenReader(p)
+		if err != nil {
+			continue
+		}
+		a.readers = append(a.readers, r)
+		for _, f := range r.File {
+			if _, exists := a.files[f.Name]; !exists {
+				a.files[f.Name] = f
+			}
+		}
+	}
+	if len(a.readers) == 0 {
+		return nil, fmt.Errorf("failed to open any APK")
+	}
+	return a, nil
+}
+
+// Close closes all underlying APK readers.
+func (a *apkFiles) Close() {
+	for _, r := range a.readers {
+		r.Close()
+	}
+}
+
+// read returns the contents of an APK entry.
+func (a *apkFiles) read(name string) ([]byte, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in APK", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// lookupResource returns all configuration values of a resource ID across every
+// APK's resources.arsc (density splits carry their own tables).
+func (a *apkFiles) lookupResource(id uint32) []ResCandidate {
+	if !a.loaded {
+		a.loaded = true
+		for _, r := range a.readers {
+			for _, f := range r.File {
+				if f.Name != "resources.arsc" {
+					continue
+				}
+				rc, err := f.Open()
+				if err != nil {
+					break
+				}
+				data, err := io.ReadAll(rc)
+				rc.Close()
+				if err != nil {
+					break
+				}
+				if table, err := ParseResTable(data); err == nil {
+					a.tables = append(a.tables, table)
+				}
+				break
+			}
+		}
+	}
+
+	var cands []ResCandidate
+	for _, t := range a.tables {
+		cands = append(cands, t.Lookup(id)...)
+	}
+	return cands
+}
+
+// decodeImage decodes a bitmap entry (PNG/WebP/JPEG) from the APK.
+func (a *apkFiles) decodeImage(name string) (image.Image, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in APK", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	img, _, err := image.Decode(rc)
+	return img, err
+}
+
+// findBestBitmap returns the highest-density mipmap/drawable bitmap with the given resource name.
+func (a *apkFiles) findBestBitmap(resName string) string {
+	var best string
+	bestRes := -1
+	for name := range a.files {
+		dir, file := path.Split(name)
+		if !strings.HasPrefix(dir, "res/mipmap") && !strings.HasPrefix(dir, "res/drawable") {
+			continue
+		}
+		ext := path.Ext(file)
+		if ext != ".png" && ext != ".webp" {
+			continue
+		}
+		if strings.TrimSuffix(file, ext) != resName {
+			continue
+		}
+		if res := extractResolution(dir); res > bestRes {
+			best, bestRes = name, res
+		}
+	}
+	return best
+}
+
+// adaptiveIconCandidates are the usual locations of adaptive launcher icon XML.
+var adaptiveIconCandidates = []string{
+	"res/mipmap-anydpi-v26/ic_launcher.xml",
+	"res/mipmap-anydpi/ic_launcher.xml",
+	"res/drawable-anydpi-v26/ic_launcher.xml",
+	"res/mipmap-anydpi-v26/app_icon.xml",
+	"res/mipmap-anydpi/app_icon.xml",
+	"res/mipmap-anydpi-v26/ic_launcher_round.xml",
+}
+
+// extractAdaptiveIcon looks for an adaptive icon XML in the APKs and loads its layers.
+func extractAdaptiveIcon(files *apkFiles, pkg string) (*AdaptiveIcon, string, error) {
+	for _, candidate := range adaptiveIconCandidates {
+		if _, ok := files.files[candidate]; !ok {
+			continue
+		}
+		logIconExtraction(pkg, "Found adaptive icon XML", candidate)
+		icon, err := loadAdaptiveIcon(files, candidate)
+		if err == nil {
+			return icon, candidate, nil
+		}
+		logIconExtraction(pkg, "Failed to load adaptive icon", candidate, err.Error())
+	}
+	return nil, "", fmt.Errorf("no adaptive icon found")
+}
+
+// loadAdaptiveIcon parses an <adaptive-icon> XML entry and resolves its layers.
+func loadAdaptiveIcon(files *apkFiles, xmlPath string) (*AdaptiveIcon, error) {
+	data, err := files.read(xmlPath)
+	if err != nil {
+		return nil, err
+	}
+	root, err := ParseAXML(data)
+	if err != nil {
+		return nil, err
+	}
+	if root.Name != "adaptive-icon" {
+		return nil, fmt.Errorf("%s is a <%s>, not an <adaptive-icon>", xmlPath, root.Name)
+	}
+
+	baseName := strings.TrimSuffix(path.Base(xmlPath), ".xml")
+	icon := &AdaptiveIcon{
+		Background: resolveAdaptiveLayer(files, root.Child("background"), baseName+"_background"),
+		Foreground: resolveAdaptiveLayer(files, root.Child("foreground"), baseName+"_foreground"),
+		Monochrome: resolveAdaptiveLayer(files, root.Child("monochrome"), baseName+"_monochrome"),
+	}
+	if icon.Foreground == nil {
+		return nil, fmt.Errorf("could not resolve foreground layer")
+	}
+	if icon.Background == nil {
+		icon.Background = solidImage(color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	}
+	return icon, nil
+}
+
+// resolveAdaptiveLayer loads one layer: an inline color, a resource resolved through
+// resources.arsc, or a bitmap found by the conventional "<icon>_<layer>" resource name.
+func resolveAdaptiveLayer(files *apkFiles, elem *XMLElement, conventionalName string) image.Image {
+	if elem == nil {
+		return nil
+	}
+
+	if attr, ok := elem.Attr("drawable", attrDrawable); ok {
+		if c, ok := attr.Color(); ok {
+			return solidImage(c)
+		}
+		if attr.IsReference() {
+			if best, ok := bestCandidate(files.lookupResource(attr.Data), false); ok {
+				if c, ok := best.Color(); ok {
+					return solidImage(c)
+				}
+				if best.Path != "" {
+					if img, err := files.decodeImage(best.Path); err == nil {
+						return img
+					}
+				}
+			}
+		}
+	}
+
+	if name := files.findBestBitmap(conventionalName); name != "" {
+		if img, err := files.decodeImage(name); err == nil {
+			return img
+		}
+	}
+	return nil
+}
+
+// solidImage returns a single-color adaptive layer.
+func solidImage(c color.NRGBA) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, adaptiveLayerDp, adaptiveLayerDp))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
@@ -0,0 +1,289 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"time"
+
+	"golang.org/x/image/webp"
+)
+
+// Frame delays below this are treated as unset, like browsers do.
+const (
+	minFrameDelay     = 20 * time.Millisecond
+	defaultFrameDelay = 100 * time.Millisecond
+)
+
+// Every frame is encoded for each icon size, so long or fast animations are thinned:
+// frames shown for less than minPlaybackDelay are dropped, and at most
+// maxAnimationFrames are kept. Dropped frames' time goes to the frame before them.
+const (
+	minPlaybackDelay   = 50 * time.Millisecond
+	maxAnimationFrames = 48
+)
+
+// AnimatedIcon is a multi-frame icon decoded from an animated GIF or WebP.
+// It behaves as its first frame wherever a still image is expected.
+type AnimatedIcon struct {
+	image.Image                 // First frame
+	Frames      []image.Image   // Fully composited frames, all the size of the canvas
+	Delays      []time.Duration // Display time of each frame
+}
+
+// NewAnimatedIcon creates an animated icon from composited frames and their delays,
+// thinned to what is worth encoding.
+func NewAnimatedIcon(frames []image.Image, delays []time.Duration) *AnimatedIcon {
+	frames, delays = thinFrames(frames, delays)
+	return &AnimatedIcon{Image: frames[0], Frames: frames, Delays: delays}
+}
+
+// thinFrames drops frames shown for less than minPlaybackDelay, then evenly drops
+// frames down to maxAnimationFrames. The total duration is kept.
+func thinFrames(frames []image.Image, delays []time.Duration) ([]image.Image, []time.Duration) {
+	keptFrames := []image.Image{frames[0]}
+	keptDelays := []time.Duration{delays[0]}
+	for i := 1; i < len(frames); i++ {
+		if delays[i] < minPlaybackDelay {
+			keptDelays[len(keptDelays)-1] += delays[i]
+			continue
+		}
+		keptFrames = append(keptFrames, frames[i])
+		keptDelays = append(keptDelays, delays[i])
+	}
+	if len(keptFrames) <= maxAnimationFrames {
+		return keptFrames, keptDelays
+	}
+
+	// Keep every step-th frame, each shown for the group it stands for
+	step := (len(keptFrames) + maxAnimationFrames - 1) / maxAnimationFrames
+	var sampledFrames []image.Image
+	var sampledDelays []time.Duration
+	for i, frame := range keptFrames {
+		if i%step == 0 {
+			sampledFrames = append(sampledFrames, frame)
+			sampledDelays = append(sampledDelays, 0)
+		}
+		sampledDelays[len(sampledDelays)-1] += keptDelays[i]
+	}
+	return sampledFrames, sampledDelays
+}
+
+// Map returns a copy of the animation with f applied to every frame.
+func (a *AnimatedIcon) Map(f func(image.Image) image.Image) *AnimatedIcon {
+	frames := make([]image.Image, len(a.Frames))
+	for i, frame := range a.Frames {
+		frames[i] = f(frame)
+	}
+	return NewAnimatedIcon(frames, a.Delays)
+}
+
+// frameDelay clamps a frame delay, replacing unset or tiny delays with the default.
+func frameDelay(d time.Duration) time.Duration {
+	if d < minFrameDelay {
+		return defaultFrameDelay
+	}
+	return d
+}
+
+// decodeAnimation decodes an animated GIF or WebP into an *AnimatedIcon
+// (or a still image if it has a single frame).
+// It returns nil without error for still images and other formats, which decode as usual.
+func decodeAnimation(data []byte) (image.Image, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("GIF8")):
+		return decodeGIFAnimation(data)
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return decodeWebPAnimation(data)
+	}
+	return nil, nil
+}
+
+// decodeGIFAnimation composites every GIF frame onto the logical screen,
+// honoring each frame's disposal method. Single-frame GIFs return nil.
+func decodeGIFAnimation(data []byte) (image.Image, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Image) < 2 {
+		return nil, nil
+	}
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		for _, frame := range g.Image {
+			bounds = bounds.Union(frame.Bounds())
+		}
+	}
+
+	canvas := image.NewRGBA(bounds)
+	frames := make([]image.Image, 0, len(g.Image))
+	delays := make([]time.Duration, 0, len(g.Image))
+	for i, frame := range g.Image {
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = cloneRGBA(canvas)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		frames = append(frames, cloneRGBA(canvas))
+		delays = append(delays, frameDelay(time.Duration(g.Delay[i])*10*time.Millisecond))
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return NewAnimatedIcon(frames, delays), nil
+}
+
+// webpChunk is one RIFF chunk of a WebP file.
+type webpChunk struct {
+	fourCC string
+	data   []byte
+}
+
+// readWebPChunks splits RIFF chunk data into chunks (payloads are padded to even sizes).
+func readWebPChunks(data []byte) ([]webpChunk, error) {
+	var chunks []webpChunk
+	for len(data) >= 8 {
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		if size < 0 || 8+size > len(data) {
+			return nil, fmt.Errorf("webp: truncated %q chunk", data[0:4])
+		}
+		chunks = append(chunks, webpChunk{fourCC: string(data[0:4]), data: data[8 : 8+size]})
+		data = data[8+size+size%2:]
+	}
+	return chunks, nil
+}
+
+// writeWebPChunk appends a RIFF chunk, padding its payload to an even size.
+func writeWebPChunk(buf *bytes.Buffer, fourCC string, data []byte) {
+	buf.WriteString(fourCC)
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if len(data)%2 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+// uint24 reads a little-endian 24-bit value.
+func uint24(b []byte) int {
+	return int(b[0]) | int(b[1])<<8 | int(b[2])<<16
+}
+
+// putUint24 writes a little-endian 24-bit value.
+func putUint24(b []byte, v int) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
+
+// decodeWebPAnimation composites the ANMF frames of an animated WebP onto its canvas.
+// Each frame's bitstream is rewrapped as a still WebP for the x/image decoder.
+// Still WebPs return nil.
+func decodeWebPAnimation(data []byte) (image.Image, error) {
+	chunks, err := readWebPChunks(data[12:])
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 || chunks[0].fourCC != "VP8X" || len(chunks[0].data) < 10 {
+		return nil, nil
+	}
+	vp8x := chunks[0].data
+	if vp8x[0]&0x02 == 0 { // Animation flag
+		return nil, nil
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, uint24(vp8x[4:])+1, uint24(vp8x[7:])+1))
+
+	var frames []image.Image
+	var delays []time.Duration
+	for _, chunk := range chunks[1:] {
+		if chunk.fourCC != "ANMF" || len(chunk.data) < 16 {
+			continue
+		}
+		h := chunk.data
+		x, y := uint24(h[0:])*2, uint24(h[3:])*2
+		w, ht := uint24(h[6:])+1, uint24(h[9:])+1
+		duration := time.Duration(uint24(h[12:])) * time.Millisecond
+		dispose := h[15]&0x01 != 0
+		blend := h[15]&0x02 == 0
+
+		frame, err := decodeWebPFrame(h[16:], w, ht)
+		if err != nil {
+			return nil, err
+		}
+
+		rect := image.Rect(x, y, x+w, y+ht)
+		op := draw.Over
+		if !blend {
+			op = draw.Src
+		}
+		draw.Draw(canvas, rect, frame, frame.Bounds().Min, op)
+		frames = append(frames, cloneRGBA(canvas))
+		delays = append(delays, frameDelay(duration))
+
+		if dispose {
+			draw.Draw(canvas, rect, image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+	switch len(frames) {
+	case 0:
+		return nil, fmt.Errorf("webp: animation has no frames")
+	case 1:
+		return frames[0], nil
+	}
+	return NewAnimatedIcon(frames, delays), nil
+}
+
+// decodeWebPFrame decodes the ALPH/VP8/VP8L chunks of one ANMF frame.
+func decodeWebPFrame(data []byte, width, height int) (image.Image, error) {
+	chunks, err := readWebPChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	hasAlpha := false
+	for _, c := range chunks {
+		if c.fourCC == "ALPH" {
+			hasAlpha = true
+		}
+	}
+	if hasAlpha {
+		// Lossy frames with alpha need a VP8X header announcing it
+		vp8x := make([]byte, 10)
+		vp8x[0] = 0x10
+		putUint24(vp8x[4:], width-1)
+		putUint24(vp8x[7:], height-1)
+		writeWebPChunk(&body, "VP8X", vp8x)
+	}
+	for _, c := range chunks {
+		switch c.fourCC {
+		case "ALPH", "VP8 ", "VP8L":
+			writeWebPChunk(&body, c.fourCC, c.data)
+		}
+	}
+
+	var file bytes.Buffer
+	file.WriteString("RIFF")
+	_ = binary.Write(&file, binary.LittleEndian, uint32(4+body.Len()))
+	file.WriteString("WEBP")
+	file.Write(body.Bytes())
+	return webp.Decode(&file)
+}
+
+// cloneRGBA returns a copy of an RGBA image.
+func cloneRGBA(src *image.RGBA) *image.RGBA {
+	dst := image.NewRGBA(src.Bounds())
+	copy(dst.Pix, src.Pix)
+	return dst
+}
@@ -2,12 +2,12 @@ package graphics
 
 import (
 	"archive/zip"
+	"context"
 	"fmt"
 	"image"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strconv"
 	"strings"
 
 	_ "golang.org/x/image/webp"
@@ -15,8 +15,8 @@ import (
 
 // getAPKPaths returns all APK paths for a given package using pm path command.
 // For App Bundles, this returns multiple paths (base + split APKs).
-func getAPKPaths(pkg string) ([]string, error) {
-	cmd := exec.Command("pm", "path", pkg)
+func getAPKPaths(ctx context.Context, pkg string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "pm", "path", pkg)
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("pm path failed: %w", err)
@@ -39,78 +39,53 @@ func getAPKPaths(pkg string) ([]string, error) {
 	return paths, nil
 }
 
-// getIconPathFromAAPT2 uses aapt2 to get the icon resource path from the APK.
-// First tries the application: line (most accurate), then falls back to application-icon lines.
-func getIconPathFromAAPT2(apkPath string) (string, error) {
-	cmd := exec.Command("aapt2", "dump", "badging", apkPath)
-	output, err := cmd.Output()
+// extractManifestIcon resolves android:icon from AndroidManifest.xml through resources.arsc.
+// Returns an adaptive icon when the best match is an <adaptive-icon> XML, else the
+// highest-density bitmap. Works for obfuscated resource names like res/BW.png.
+func extractManifestIcon(files *apkFiles, pkg string) (*AdaptiveIcon, image.Image, string, error) {
+	data, err := files.read("AndroidManifest.xml")
 	if err != nil {
-		return "", fmt.Errorf("aapt2 failed: %w", err)
+		return nil, nil, "", err
 	}
-
-	lines := strings.Split(string(output), "\n")
-
-	// First, try to get icon from the application: line
-	// Format: application: label='AppName' icon='res/XX.png' ...
-	for _, line := range lines {
-		if !strings.HasPrefix(line, "application:") {
-			continue
-		}
-
-		// Extract icon='...' from the line
-		if idx := strings.Index(line, "icon='"); idx != -1 {
-			start := idx + 6 // len("icon='")
-			end := strings.Index(line[start:], "'")
-			if end != -1 {
-				iconPath := line[start : start+end]
-				// If it's XML, try PNG version
-				if strings.HasSuffix(iconPath, ".xml") {
-					pngPath := strings.TrimSuffix(iconPath, ".xml") + ".png"
-					return pngPath, nil
-				}
-				if strings.HasSuffix(iconPath, ".png") {
-					return iconPath, nil
-				}
-			}
-		}
+	manifest, err := ParseAXML(data)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to parse manifest: %w", err)
 	}
+	application := manifest.Child("application")
+	if application == nil {
+		return nil, nil, "", fmt.Errorf("manifest has no <application>")
+	}
+	iconAttr, ok := application.Attr("icon", attrIcon)
+	if !ok || !iconAttr.IsReference() {
+		return nil, nil, "", fmt.Errorf("manifest has no android:icon")
+	}
+	logIconExtraction(pkg, "Manifest icon resource", fmt.Sprintf("0x%08x", iconAttr.Data))
 
-	// Fallback: Parse application-icon lines
-	// Format: application-icon-640:'res/BW.xml' or application-icon-640:'res/BW.png'
-	var highestResIcon string
-	highestRes := 0
-
-	for _, line := range lines {
-		if !strings.HasPrefix(line, "application-icon-") {
-			continue
-		}
-
-		parts := strings.SplitN(line, ":", 2)
-		if len(parts) != 2 {
-			continue
-		}
-
-		resPart := strings.TrimPrefix(parts[0], "application-icon-")
-		res, _ := strconv.Atoi(resPart)
-		path := strings.Trim(parts[1], "'")
+	cands := files.lookupResource(iconAttr.Data)
+	if len(cands) == 0 {
+		return nil, nil, "", fmt.Errorf("icon resource 0x%08x not found in resources.arsc", iconAttr.Data)
+	}
 
-		if strings.HasSuffix(path, ".png") {
-			if res > highestRes {
-				highestRes = res
-				highestResIcon = path
-			}
-		} else if strings.HasSuffix(path, ".xml") && highestResIcon == "" {
-			pngPath := strings.TrimSuffix(path, ".xml") + ".png"
-			highestResIcon = pngPath
-			highestRes = res
+	// Adaptive icon XML first
+	if best, ok := bestCandidate(cands, true); ok && isXMLPath(best.Path) {
+		logIconExtraction(pkg, "Resolved icon XML", best.Path)
+		adaptive, err := loadAdaptiveIcon(files, best.Path)
+		if err == nil {
+			return adaptive, nil, best.Path, nil
 		}
+		logIconExtraction(pkg, "Failed to load adaptive icon", best.Path, err.Error())
 	}
 
-	if highestResIcon == "" {
-		return "", fmt.Errorf("no icon found via aapt2")
+	// Otherwise the highest-density bitmap
+	best, ok := bestCandidate(cands, false)
+	if !ok || best.Path == "" {
+		return nil, nil, "", fmt.Errorf("no bitmap for icon resource 0x%08x", iconAttr.Data)
 	}
-
-	return highestResIcon, nil
+	img, err := files.decodeImage(best.Path)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to decode %s: %w", best.Path, err)
+	}
+	return nil, img, best.Path, nil
 }
 
 // extractIconFromAPK extracts icon directly from APK using mipmap patterns.
@@ -215,79 +190,7 @@ func extractIconFromAPK(apkPath string, pkg string) (image.Image, string, error)
 		}
 	}
 
-	// PRIORITY 2: Try ADB (pm dump) to get icon path
-	if pkg != "" {
-		logIconExtraction(pkg, "Trying ADB (pm dump)")
-		iconPath, err := getIconPathViaADB(pkg)
-		if err == nil && iconPath != "" {
-			logIconExtraction(pkg, "ADB returned path", iconPath)
-			if f, ok := fileMap[iconPath]; ok {
-				rc, err := f.Open()
-				if err == nil {
-					defer rc.Close()
-					img, _, err := image.Decode(rc)
-					if err == nil {
-						logIconExtraction(pkg, "Successfully decoded from ADB path", iconPath)
-						return img, iconPath, nil
-					}
-				}
-			}
-			// If ADB returned XML path, try PNG version
-			if strings.HasSuffix(iconPath, ".xml") {
-				pngPath := strings.TrimSuffix(iconPath, ".xml") + ".png"
-				logIconExtraction(pkg, "Trying PNG version of XML", pngPath)
-				if f, ok := fileMap[pngPath]; ok {
-					rc, err := f.Open()
-					if err == nil {
-						defer rc.Close()
-						img, _, err := image.Decode(rc)
-						if err == nil {
-							logIconExtraction(pkg, "Successfully decoded PNG", pngPath)
-							return img, pngPath, nil
-						}
-					}
-				}
-			}
-		} else if err != nil {
-			logIconExtraction(pkg, "ADB failed", err.Error())
-		}
-	}
-
-	// PRIORITY 3: Try aapt2
-	logIconExtraction(pkg, "Trying aapt2")
-	iconPath, err := getIconPathFromAAPT2(apkPath)
-	if err == nil && iconPath != "" {
-		logIconExtraction(pkg, "aapt2 returned path", iconPath)
-		if f, ok := fileMap[iconPath]; ok {
-			rc, err := f.Open()
-			if err == nil {
-				defer rc.Close()
-				img, _, err := image.Decode(rc)
-				if err == nil {
-					logIconExtraction(pkg, "Successfully decoded from aapt2 path", iconPath)
-					return img, iconPath, nil
-				}
-			}
-		}
-		// If aapt2 returned XML path, try PNG version
-		if strings.HasSuffix(iconPath, ".xml") {
-			pngPath := strings.TrimSuffix(iconPath, ".xml") + ".png"
-			if f, ok := fileMap[pngPath]; ok {
-				rc, err := f.Open()
-				if err == nil {
-					defer rc.Close()
-					img, _, err := image.Decode(rc)
-					if err == nil {
-						return img, pngPath, nil
-					}
-				}
-			}
-		}
-	} else if err != nil {
-		logIconExtraction(pkg, "aapt2 failed", err.Error())
-	}
-
-	// PRIORITY 4: Drawable fallbacks
+	// PRIORITY 2: Drawable fallbacks
 	drawablePatterns := []string{
 		"res/drawable-xxxhdpi-v4/ic_launcher.png",
 		"res/drawable-xxxhdpi/ic_launcher.png",
@@ -329,7 +232,7 @@ func extractIconFromAPK(apkPath string, pkg string) (image.Image, string, error)
 		}
 	}
 
-	// PRIORITY 5: Any PNG/WebP in mipmap (largest) - ONLY in base APK
+	// PRIORITY 3: Any PNG/WebP in mipmap (largest) - ONLY in base APK
 	// Skip this for split APKs to avoid picking up random images
 	if !strings.Contains(apkPath, "split_config.") {
 		logIconExtraction(pkg, "Looking for any mipmap image in base APK")
@@ -369,50 +272,91 @@ func extractIconFromAPK(apkPath string, pkg string) (image.Image, string, error)
 
 // ExtractAPKIcon extracts the app icon from an APK file.
 // For App Bundles, searches through all split APKs.
+// Adaptive icons are composed from their layers and masked to shape.
 // Icons are cached to avoid repeated extraction.
-func ExtractAPKIcon(pkg string) (image.Image, error) {
+func ExtractAPKIcon(ctx context.Context, pkg string, shape string) (image.Image, error) {
+	img, _, err := ExtractAPKIconLayers(ctx, pkg, shape)
+	return img, err
+}
+
+// ExtractAPKIconLayers is ExtractAPKIcon that also returns the adaptive icon's
+// layers, or nil for legacy bitmap icons.
+// Extraction stops early with ctx's error once ctx is done.
+func ExtractAPKIconLayers(ctx context.Context, pkg string, shape string) (image.Image, *AdaptiveIcon, error) {
 	if pkg == "" {
-		return nil, fmt.Errorf("empty package name")
+		return nil, nil, fmt.Errorf("empty package name")
 	}
 
 	logIconExtraction(pkg, "Starting icon extraction")
 
-	// Check Tier 1 cache first (PNG icon)
+	// Check Tier 1 cache for adaptive layers first, then the plain PNG icon
+	if adaptive, err := loadCachedAdaptiveIcon(pkg); err == nil {
+		logIconExtraction(pkg, "Tier 1 adaptive cache hit")
+		return adaptive.Compose(shape), adaptive, nil
+	}
 	cachePath := getCachedIconPath(pkg)
 	if cached, err := LoadImage(cachePath); err == nil {
 		logIconExtraction(pkg, "Tier 1 cache hit", cachePath)
-		return cached, nil
+		return cached, nil, nil
 	}
 	logIconExtraction(pkg, "Tier 1 cache miss")
 
 	// Get all APK paths (base + splits for App Bundles)
-	apkPaths, err := getAPKPaths(pkg)
+	apkPaths, err := getAPKPaths(ctx, pkg)
 	if err != nil {
 		logIconExtraction(pkg, "Failed to get APK paths", err.Error())
-		return nil, err
+		return nil, nil, err
 	}
 	logIconExtraction(pkg, "Found APKs", fmt.Sprintf("%d paths", len(apkPaths)))
 	for i, path := range apkPaths {
 		logDebug("  APK[%d]: %s", i, path)
 	}
 
-	// Try to extract icon from each APK (base first, then splits)
+	// Resolve the icon through the manifest and resources.arsc, then by adaptive
+	// icon naming conventions. Layers and densities may be spread over base and split APKs.
 	var img image.Image
 	var iconSource string
 	var lastErr error
-	for _, apkPath := range apkPaths {
-		img, iconSource, err = extractIconFromAPK(apkPath, pkg)
-		if err == nil {
-			logIconExtraction(pkg, "Icon extracted successfully", iconSource)
-			break
+	if files, err := openAPKFiles(apkPaths); err == nil {
+		var adaptive *AdaptiveIcon
+		adaptive, img, iconSource, err = extractManifestIcon(files, pkg)
+		if err != nil {
+			logIconExtraction(pkg, "Manifest icon resolution failed", err.Error())
+			adaptive, iconSource, err = extractAdaptiveIcon(files, pkg)
+		}
+		files.Close()
+
+		if adaptive != nil {
+			logIconExtraction(pkg, "Adaptive icon extracted successfully", iconSource)
+			_ = saveAdaptiveIconCache(pkg, adaptive)
+			return adaptive.Compose(shape), adaptive, nil
+		}
+		if err != nil {
+			logIconExtraction(pkg, "No adaptive icon", err.Error())
+		}
+	}
+
+	if img != nil {
+		logIconExtraction(pkg, "Icon resolved from manifest", iconSource)
+	} else {
+		// Fall back to well-known icon paths in each APK (base first, then splits)
+		for _, apkPath := range apkPaths {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+			img, iconSource, err = extractIconFromAPK(apkPath, pkg)
+			if err == nil {
+				logIconExtraction(pkg, "Icon extracted successfully", iconSource)
+				break
+			}
+			logIconExtraction(pkg, "Failed to extract from APK", apkPath, err.Error())
+			lastErr = err
 		}
-		logIconExtraction(pkg, "Failed to extract from APK", apkPath, err.Error())
-		lastErr = err
 	}
 
 	if img == nil {
 		logIconExtraction(pkg, "All extraction methods failed", lastErr.Error())
-		return nil, fmt.Errorf("could not extract icon from any APK: %w", lastErr)
+		return nil, nil, fmt.Errorf("could not extract icon from any APK: %w", lastErr)
 	}
 
 	// Save to Tier 1 cache
@@ -420,5 +364,5 @@ func ExtractAPKIcon(pkg string) (image.Image, error) {
 	_ = os.MkdirAll(filepath.Dir(cachePath), 0755)
 	_ = SaveImage(img, cachePath)
 
-	return img, nil
+	return img, nil, nil
 }
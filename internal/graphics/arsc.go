@@ -0,0 +1,279 @@
+package graphics
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image/color"
+)
+
+// ResTable_type flags.
+const (
+	typeFlagSparse   = 0x01
+	typeFlagOffset16 = 0x02
+)
+
+// ResTable_entry flags.
+const (
+	entryFlagComplex = 0x0001
+	entryFlagCompact = 0x0008
+)
+
+// Special ResTable_config density values.
+const (
+	densityDefault = 0
+	densityAny     = 0xfffe
+	densityNone    = 0xffff
+)
+
+// maxAliasDepth bounds how many resource references are followed when resolving an ID.
+const maxAliasDepth = 8
+
+// ResTable is a parsed resources.arsc table.
+// Only the structure is indexed up front; entries are decoded when looked up.
+type ResTable struct {
+	strings  *stringPool
+	packages map[uint8]*resPackage
+}
+
+// resPackage holds the type chunks of one resource package, keyed by type ID.
+type resPackage struct {
+	types map[uint8][][]byte
+}
+
+// ResCandidate is one configuration-specific value of a resource.
+type ResCandidate struct {
+	Density   int    // Screen density in dpi (0 default, 0xfffe anydpi)
+	Night     bool   // Configuration only applies in night mode
+	Localized bool   // Configuration is restricted to a language/region
+	DataType  uint8  // Res_value data type
+	Data      uint32 // Res_value data
+	Path      string // File path for string values (e.g. "res/mipmap-xxhdpi-v4/ic_launcher.png")
+}
+
+// Color returns the candidate as a color if it is a color literal.
+func (c ResCandidate) Color() (color.NRGBA, bool) {
+	return resColor(c.DataType, c.Data)
+}
+
+// ParseResTable indexes a resources.arsc file.
+func ParseResTable(data []byte) (*ResTable, error) {
+	if len(data) < 12 || binary.LittleEndian.Uint16(data) != resTableType {
+		return nil, fmt.Errorf("not a resources.arsc table")
+	}
+
+	t := &ResTable{packages: make(map[uint8]*resPackage)}
+	offset := int(binary.LittleEndian.Uint16(data[2:]))
+	for offset+chunkHeaderMinBytes <= len(data) {
+		chunkType := binary.LittleEndian.Uint16(data[offset:])
+		chunkSize := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		if chunkSize < chunkHeaderMinBytes || offset+chunkSize > len(data) {
+			return nil, fmt.Errorf("corrupt table chunk at offset %d", offset)
+		}
+		chunk := data[offset : offset+chunkSize]
+
+		switch chunkType {
+		case resStringPoolType:
+			pool, err := newStringPool(chunk)
+			if err != nil {
+				return nil, err
+			}
+			t.strings = pool
+		case resTablePackageType:
+			if err := t.indexPackage(chunk); err != nil {
+				return nil, err
+			}
+		}
+		offset += chunkSize
+	}
+
+	return t, nil
+}
+
+// indexPackage records the type chunks of a package chunk.
+func (t *ResTable) indexPackage(chunk []byte) error {
+	if len(chunk) < 12 {
+		return fmt.Errorf("truncated package chunk")
+	}
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	id := uint8(binary.LittleEndian.Uint32(chunk[8:]))
+
+	pkg := t.packages[id]
+	if pkg == nil {
+		pkg = &resPackage{types: make(map[uint8][][]byte)}
+		t.packages[id] = pkg
+	}
+
+	offset := headerSize
+	for offset+chunkHeaderMinBytes <= len(chunk) {
+		chunkType := binary.LittleEndian.Uint16(chunk[offset:])
+		chunkSize := int(binary.LittleEndian.Uint32(chunk[offset+4:]))
+		if chunkSize < chunkHeaderMinBytes || offset+chunkSize > len(chunk) {
+			return fmt.Errorf("corrupt package chunk at offset %d", offset)
+		}
+		if chunkType == resTableTypeType && chunkSize > 20 {
+			typeID := chunk[offset+8]
+			pkg.types[typeID] = append(pkg.types[typeID], chunk[offset:offset+chunkSize])
+		}
+		offset += chunkSize
+	}
+	return nil
+}
+
+// Lookup returns all configuration values of a resource ID, following
+// references (aliases such as @mipmap/ic_launcher -> @drawable/icon).
+func (t *ResTable) Lookup(id uint32) []ResCandidate {
+	return t.lookup(id, 0)
+}
+
+func (t *ResTable) lookup(id uint32, depth int) []ResCandidate {
+	if depth > maxAliasDepth {
+		return nil
+	}
+	pkg := t.packages[uint8(id>>24)]
+	if pkg == nil {
+		return nil
+	}
+	typeID := uint8(id >> 16)
+	entryID := uint16(id)
+
+	var result []ResCandidate
+	for _, chunk := range pkg.types[typeID] {
+		cand, ok := t.readEntry(chunk, entryID)
+		if !ok {
+			continue
+		}
+		if cand.DataType == resTypeReference && cand.Data != 0 && cand.Data != id {
+			// Alias: the referenced resource's configs replace this one
+			result = append(result, t.lookup(cand.Data, depth+1)...)
+			continue
+		}
+		result = append(result, cand)
+	}
+	return result
+}
+
+// readEntry decodes the value of one entry from a ResTable_type chunk.
+func (t *ResTable) readEntry(chunk []byte, entryID uint16) (ResCandidate, bool) {
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	flags := chunk[9]
+	entryCount := int(binary.LittleEndian.Uint32(chunk[12:]))
+	entriesStart := int(binary.LittleEndian.Uint32(chunk[16:]))
+	if headerSize > len(chunk) || 20 > headerSize {
+		return ResCandidate{}, false
+	}
+
+	cand := parseResConfig(chunk[20:headerSize])
+
+	// Find the entry offset in the offsets table
+	offsets := chunk[headerSize:]
+	entryOffset := -1
+	switch {
+	case flags&typeFlagSparse != 0:
+		// Sorted (index u16, offset/4 u16) pairs
+		for i := 0; i < entryCount && i*4+4 <= len(offsets); i++ {
+			if binary.LittleEndian.Uint16(offsets[i*4:]) == entryID {
+				entryOffset = int(binary.LittleEndian.Uint16(offsets[i*4+2:])) * 4
+				break
+			}
+		}
+	case flags&typeFlagOffset16 != 0:
+		if int(entryID) < entryCount && int(entryID)*2+2 <= len(offsets) {
+			if v := binary.LittleEndian.Uint16(offsets[int(entryID)*2:]); v != 0xffff {
+				entryOffset = int(v) * 4
+			}
+		}
+	default:
+		if int(entryID) < entryCount && int(entryID)*4+4 <= len(offsets) {
+			if v := binary.LittleEndian.Uint32(offsets[int(entryID)*4:]); v != axmlNoIndex {
+				entryOffset = int(v)
+			}
+		}
+	}
+	if entryOffset < 0 {
+		return ResCandidate{}, false
+	}
+
+	e := entriesStart + entryOffset
+	if e+8 > len(chunk) {
+		return ResCandidate{}, false
+	}
+	entryFlags := binary.LittleEndian.Uint16(chunk[e+2:])
+
+	switch {
+	case entryFlags&entryFlagCompact != 0:
+		// Compact entry: key u16, flags u16 (data type in high byte), data u32
+		cand.DataType = uint8(entryFlags >> 8)
+		cand.Data = binary.LittleEndian.Uint32(chunk[e+4:])
+	case entryFlags&entryFlagComplex != 0:
+		// Bags (styles, arrays) are not icon resources
+		return ResCandidate{}, false
+	default:
+		entrySize := int(binary.LittleEndian.Uint16(chunk[e:]))
+		v := e + entrySize
+		if v+8 > len(chunk) {
+			return ResCandidate{}, false
+		}
+		cand.DataType = chunk[v+3]
+		cand.Data = binary.LittleEndian.Uint32(chunk[v+4:])
+	}
+
+	if cand.DataType == resTypeString {
+		cand.Path = t.strings.get(cand.Data)
+	}
+	return cand, true
+}
+
+// parseResConfig extracts the qualifiers relevant to icon selection from a ResTable_config.
+func parseResConfig(cfg []byte) ResCandidate {
+	var c ResCandidate
+	if len(cfg) >= 16 {
+		c.Localized = cfg[8] != 0 || cfg[10] != 0 // language[0] or country[0]
+		c.Density = int(binary.LittleEndian.Uint16(cfg[14:]))
+	}
+	if len(cfg) >= 30 {
+		c.Night = cfg[29]&0x30 == 0x20 // UI_MODE_NIGHT_YES
+	}
+	return c
+}
+
+// bestCandidate picks the preferred value among candidates: unlocalized and
+// not night-only configs first, then the highest density. When wantXML is
+// set, anydpi XML drawables (adaptive icons) win over bitmaps.
+func bestCandidate(cands []ResCandidate, wantXML bool) (ResCandidate, bool) {
+	best, bestScore := ResCandidate{}, -1
+	for _, c := range cands {
+		score := candidateDensity(c.Density)
+		if !c.Localized {
+			score += 1 << 20
+		}
+		if !c.Night {
+			score += 1 << 19
+		}
+		if isXMLPath(c.Path) {
+			if !wantXML {
+				continue
+			}
+			score += 1 << 18
+		}
+		if score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+// candidateDensity ranks a config density; anydpi/nodpi rank above every bitmap density.
+func candidateDensity(density int) int {
+	switch density {
+	case densityAny, densityNone:
+		return 0xffff
+	case densityDefault:
+		return 160 // Default resources are mdpi
+	}
+	return density
+}
+
+// isXMLPath reports whether a resource file path is a compiled XML drawable.
+func isXMLPath(p string) bool {
+	return len(p) > 4 && p[len(p)-4:] == ".xml"
+}
@@ -0,0 +1,266 @@
+package graphics
+
+import (
+	"image/color"
+	"testing"
+)
+
+// resConfigBytes builds a ResTable_config with a density, night mode and language.
+func resConfigBytes(density uint16, night bool, lang string) []byte {
+	cfg := make([]byte, 64)
+	copy(cfg, le(nil, uint32(len(cfg))))
+	copy(cfg[8:10], lang)
+	copy(cfg[14:], le(nil, density))
+	if night {
+		cfg[29] = 0x20
+	}
+	return cfg
+}
+
+// valueEntry builds a simple ResTable_entry holding a Res_value.
+func valueEntry(dataType uint8, data uint32) []byte {
+	return le(nil, uint16(8), uint16(0), uint32(0), uint16(8), uint8(0), dataType, data)
+}
+
+// compactEntry builds a compact ResTable_entry holding a value inline.
+func compactEntry(dataType uint8, data uint32) []byte {
+	return le(nil, uint16(0), uint16(entryFlagCompact)|uint16(dataType)<<8, data)
+}
+
+// bagEntry builds a complex ResTable_entry with no values, as styles and arrays use.
+func bagEntry() []byte {
+	return le(nil, uint16(16), uint16(entryFlagComplex), uint32(0), uint32(0), uint32(0))
+}
+
+// typeChunk builds a ResTable_type chunk holding entries by entry ID, nil for missing
+// ones. flags selects the offsets table layout: dense, 16-bit or sparse.
+func typeChunk(typeID, flags uint8, cfg []byte, entries [][]byte) []byte {
+	var offsets, data []byte
+	count := 0
+	for i, e := range entries {
+		switch {
+		case flags&typeFlagSparse != 0:
+			if e != nil {
+				offsets = le(offsets, uint16(i), uint16(len(data)/4))
+				count++
+			}
+		case flags&typeFlagOffset16 != 0:
+			offset := uint16(0xffff)
+			if e != nil {
+				offset = uint16(len(data) / 4)
+			}
+			offsets = le(offsets, offset)
+			count++
+		default:
+			offset := noIndex
+			if e != nil {
+				offset = uint32(len(data))
+			}
+			offsets = le(offsets, offset)
+			count++
+		}
+		data = append(data, e...)
+	}
+	for len(offsets)%4 != 0 {
+		offsets = append(offsets, 0)
+	}
+
+	headerSize := 20 + len(cfg)
+	header := le(nil, typeID, flags, uint16(0), uint32(count), uint32(headerSize+len(offsets)))
+	return resChunk(resTableTypeType, append(header, cfg...), append(offsets, data...))
+}
+
+// packageChunk builds a ResTable_package chunk of type chunks.
+func packageChunk(id uint32, types ...[]byte) []byte {
+	header := le(nil, id, make([]byte, 256), uint32(0), uint32(0), uint32(0), uint32(0), uint32(0))
+	var body []byte
+	for _, t := range types {
+		body = append(body, t...)
+	}
+	return resChunk(resTablePackageType, header, body)
+}
+
+// Resource IDs of testResTable.
+const (
+	testLauncher    = 0x7f010000 // mipmap/ic_launcher: xxhdpi PNG, anydpi XML, French mdpi PNG
+	testNight       = 0x7f010001 // mipmap/ic_night: mdpi PNG, night-only xhdpi PNG
+	testAlias       = 0x7f020000 // drawable/alias -> mipmap/ic_launcher
+	testColor       = 0x7f020001 // drawable/color: compact color literal
+	testBag         = 0x7f020002 // drawable/bag: complex entry
+	testLoopA       = 0x7f020003 // drawable/loop_a -> loop_b
+	testLoopB       = 0x7f020004 // drawable/loop_b -> loop_a
+	testSelfRef     = 0x7f020005 // drawable/self -> itself
+	testMissing     = 0x7f020006 // Beyond the entries of drawable
+	testNoPackage   = 0x01010000 // Framework package, not in the table
+	testMissingType = 0x7f030000 // Type without chunks
+)
+
+// testResTable builds a resources.arsc table exercising each offsets table layout,
+// entry kind and configuration qualifier.
+func testResTable() []byte {
+	pool := stringPoolChunk([]string{
+		"res/mipmap-xxhdpi-v4/ic_launcher.png",
+		"res/mipmap-anydpi-v26/ic_launcher.xml",
+		"res/mipmap-mdpi-fr/ic_launcher.png",
+		"res/mipmap-mdpi/ic_night.png",
+		"res/mipmap-night-xhdpi/ic_night.png",
+	}, true)
+
+	pkg := packageChunk(0x7f,
+		typeChunk(0x01, 0, resConfigBytes(480, false, ""), [][]byte{
+			valueEntry(resTypeString, 0),
+		}),
+		typeChunk(0x01, typeFlagOffset16, resConfigBytes(densityAny, false, ""), [][]byte{
+			valueEntry(resTypeString, 1),
+		}),
+		typeChunk(0x01, typeFlagSparse, resConfigBytes(160, false, "fr"), [][]byte{
+			valueEntry(resTypeString, 2),
+		}),
+		typeChunk(0x01, typeFlagOffset16, resConfigBytes(160, false, ""), [][]byte{
+			nil,
+			valueEntry(resTypeString, 3),
+		}),
+		typeChunk(0x01, typeFlagSparse, resConfigBytes(320, true, ""), [][]byte{
+			nil,
+			valueEntry(resTypeString, 4),
+		}),
+		typeChunk(0x02, 0, resConfigBytes(densityDefault, false, ""), [][]byte{
+			valueEntry(resTypeReference, testLauncher),
+			compactEntry(resTypeARGB8, 0xff3366cc),
+			bagEntry(),
+			valueEntry(resTypeReference, testLoopB),
+			valueEntry(resTypeReference, testLoopA),
+			valueEntry(resTypeReference, testSelfRef),
+		}),
+	)
+
+	return resChunk(resTableType, le(nil, uint32(1)), append(pool, pkg...))
+}
+
+func TestResTableLookup(t *testing.T) {
+	table, err := ParseResTable(testResTable())
+	if err != nil {
+		t.Fatalf("ParseResTable: %v", err)
+	}
+
+	launcher := []ResCandidate{
+		{Density: 480, DataType: resTypeString, Path: "res/mipmap-xxhdpi-v4/ic_launcher.png"},
+		{Density: densityAny, DataType: resTypeString, Data: 1, Path: "res/mipmap-anydpi-v26/ic_launcher.xml"},
+		{Density: 160, Localized: true, DataType: resTypeString, Data: 2, Path: "res/mipmap-mdpi-fr/ic_launcher.png"},
+	}
+	for _, tt := range []struct {
+		name string
+		id   uint32
+		want []ResCandidate
+	}{
+		{"every config", testLauncher, launcher},
+		{"missing entries skipped", testNight, []ResCandidate{
+			{Density: 160, DataType: resTypeString, Data: 3, Path: "res/mipmap-mdpi/ic_night.png"},
+			{Density: 320, Night: true, DataType: resTypeString, Data: 4, Path: "res/mipmap-night-xhdpi/ic_night.png"},
+		}},
+		{"alias followed", testAlias, launcher},
+		{"compact color", testColor, []ResCandidate{{Density: densityDefault, DataType: resTypeARGB8, Data: 0xff3366cc}}},
+		{"bag skipped", testBag, nil},
+		{"reference loop bounded", testLoopA, nil},
+		{"self reference kept", testSelfRef, []ResCandidate{{DataType: resTypeReference, Data: testSelfRef}}},
+		{"missing entry", testMissing, nil},
+		{"missing package", testNoPackage, nil},
+		{"missing type", testMissingType, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := table.Lookup(tt.id)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Lookup(%#x) = %+v, want %+v", tt.id, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Lookup(%#x)[%d] = %+v, want %+v", tt.id, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if c, ok := table.Lookup(testColor)[0].Color(); !ok || c != (color.NRGBA{R: 0x33, G: 0x66, B: 0xcc, A: 0xff}) {
+		t.Errorf("color = %v, %v", c, ok)
+	}
+}
+
+func TestBestCandidate(t *testing.T) {
+	table, err := ParseResTable(testResTable())
+	if err != nil {
+		t.Fatalf("ParseResTable: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		id      uint32
+		wantXML bool
+		want    string
+	}{
+		{"adaptive XML preferred", testLauncher, true, "res/mipmap-anydpi-v26/ic_launcher.xml"},
+		{"XML skipped for bitmaps", testLauncher, false, "res/mipmap-xxhdpi-v4/ic_launcher.png"},
+		{"night only ranks below lower density", testNight, false, "res/mipmap-mdpi/ic_night.png"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := bestCandidate(table.Lookup(tt.id), tt.wantXML)
+			if !ok || got.Path != tt.want {
+				t.Errorf("bestCandidate = %q, %v; want %q", got.Path, ok, tt.want)
+			}
+		})
+	}
+
+	if got, ok := bestCandidate(nil, true); ok {
+		t.Errorf("bestCandidate(nil) = %+v, want none", got)
+	}
+}
+
+func TestParseResTableRejects(t *testing.T) {
+	valid := testResTable()
+	oversized := append([]byte(nil), valid...)
+	copy(oversized[12+4:], le(nil, uint32(1<<30)))
+
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"short header", valid[:8]},
+		{"binary XML", resChunk(resXMLType, le(nil, uint32(0)), nil)},
+		{"chunk past end", oversized},
+		{"truncated package", resChunk(resTableType, le(nil, uint32(1)), resChunk(resTablePackageType, nil, nil))},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if table, err := ParseResTable(tt.data); err == nil {
+				t.Errorf("ParseResTable = %+v, want error", table)
+			}
+		})
+	}
+}
+
+func TestParseResTableCorrupt(t *testing.T) {
+	valid := testResTable()
+	ids := []uint32{testLauncher, testNight, testAlias, testColor, testBag, testLoopA, testSelfRef, testMissing}
+	lookupAll := func(data []byte) {
+		table, err := ParseResTable(data)
+		if err != nil {
+			return
+		}
+		for _, id := range ids {
+			if c, ok := bestCandidate(table.Lookup(id), true); ok {
+				_, _ = c.Color()
+			}
+		}
+	}
+
+	// Must fail or find fewer values, never panic
+	for n := range valid {
+		lookupAll(valid[:n])
+	}
+	for i := range valid {
+		for _, b := range []byte{0x00, 0x7f, 0xff} {
+			corrupt := append([]byte(nil), valid...)
+			corrupt[i] = b
+			lookupAll(corrupt)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package graphics
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"unicode/utf16"
+)
+
+// Android binary XML and resource table chunk types.
+const (
+	resStringPoolType   = 0x0001
+	resTableType        = 0x0002
+	resXMLType          = 0x0003
+	resTablePackageType = 0x0200
+	resTableTypeType    = 0x0201
+	resXMLStartElement  = 0x0102
+	resXMLEndElement    = 0x0103
+	resXMLResourceMap   = 0x0180
+	stringPoolUTF8Flag  = 1 << 8
+	axmlNoIndex         = 0xFFFFFFFF
+	chunkHeaderMinBytes = 8
+)
+
+// Res_value data types used by icon resources.
+const (
+	resTypeReference = 0x01
+	resTypeString    = 0x03
+	resTypeARGB8     = 0x1c
+	resTypeRGB8      = 0x1d
+	resTypeARGB4     = 0x1e
+	resTypeRGB4      = 0x1f
+)
+
+// Android framework attribute resource IDs, used when attribute names are stripped.
+const (
+	attrDrawable = 0x01010199
+	attrColor    = 0x010101a5
+	attrIcon     = 0x01010002
+)
+
+// XMLAttr is a single attribute of a binary XML element.
+type XMLAttr struct {
+	Name     string // Attribute name (may be empty in obfuscated APKs)
+	ResID    uint32 // Framework attribute resource ID, 0 if unknown
+	Raw      string // Raw string value, if any
+	DataType uint8  // Res_value data type
+	Data     uint32 // Res_value data (resource ID, color, int...)
+}
+
+// IsReference reports whether the attribute points to another resource.
+func (a XMLAttr) IsReference() bool {
+	return a.DataType == resTypeReference && a.Data != 0
+}
+
+// Color returns the attribute's value as a color if it is an inline color literal.
+func (a XMLAttr) Color() (color.NRGBA, bool) {
+	return resColor(a.DataType, a.Data)
+}
+
+// XMLElement is a parsed binary XML element.
+type XMLElement struct {
+	Name     string
+	Attrs    []XMLAttr
+	Children []*XMLElement
+}
+
+// Attr returns the attribute matching a name or framework resource ID.
+func (e *XMLElement) Attr(name string, resID uint32) (XMLAttr, bool) {
+	for _, a := range e.Attrs {
+		if (resID != 0 && a.ResID == resID) || (name != "" && a.Name == name) {
+			return a, true
+		}
+	}
+	return XMLAttr{}, false
+}
+
+// Child returns the first child element with the given name.
+func (e *XMLElement) Child(name string) *XMLElement {
+	for _, c := range e.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// ParseAXML parses an Android binary XML document and returns its root element.
+func ParseAXML(data []byte) (*XMLElement, error) {
+	if len(data) < chunkHeaderMinBytes || binary.LittleEndian.Uint16(data) != resXMLType {
+		return nil, fmt.Errorf("not an Android binary XML file")
+	}
+
+	var strings []string
+	var resMap []uint32
+	var root *XMLElement
+	var stack []*XMLElement
+
+	offset := int(binary.LittleEndian.Uint16(data[2:]))
+	for offset+chunkHeaderMinBytes <= len(data) {
+		chunkType := binary.LittleEndian.Uint16(data[offset:])
+		headerSize := int(binary.LittleEndian.Uint16(data[offset+2:]))
+		chunkSize := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		if chunkSize < chunkHeaderMinBytes || offset+chunkSize > len(data) {
+			return nil, fmt.Errorf("corrupt chunk at offset %d", offset)
+		}
+		chunk := data[offset : offset+chunkSize]
+
+		switch chunkType {
+		case resStringPoolType:
+			pool, err := parseStringPool(chunk)
+			if err != nil {
+				return nil, err
+			}
+			strings = pool
+
+		case resXMLResourceMap:
+			for i := headerSize; i+4 <= len(chunk); i += 4 {
+				resMap = append(resMap, binary.LittleEndian.Uint32(chunk[i:]))
+			}
+
+		case resXMLStartElement:
+			elem, err := parseStartElement(chunk, headerSize, strings, resMap)
+			if err != nil {
+				return nil, err
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, elem)
+			} else if root == nil {
+				root = elem
+			}
+			stack = append(stack, elem)
+
+		case resXMLEndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+
+		offset += chunkSize
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
+
+// parseStartElement decodes a RES_XML_START_ELEMENT chunk.
+func parseStartElement(chunk []byte, headerSize int, strings []string, resMap []uint32) (*XMLElement, error) {
+	ext := headerSize
+	if ext+20 > len(chunk) {
+		return nil, fmt.Errorf("truncated start element")
+	}
+
+	elem := &XMLElement{Name: poolString(strings, binary.LittleEndian.Uint32(chunk[ext+4:]))}
+	attrStart := int(binary.LittleEndian.Uint16(chunk[ext+8:]))
+	attrSize := int(binary.LittleEndian.Uint16(chunk[ext+10:]))
+	attrCount := int(binary.LittleEndian.Uint16(chunk[ext+12:]))
+
+	for i := 0; i < attrCount; i++ {
+		a := ext + attrStart + i*attrSize
+		if a+20 > len(chunk) {
+			break
+		}
+		nameIdx := binary.LittleEndian.Uint32(chunk[a+4:])
+		attr := XMLAttr{
+			Name:     poolString(strings, nameIdx),
+			Raw:      poolString(strings, binary.LittleEndian.Uint32(chunk[a+8:])),
+			DataType: chunk[a+15],
+			Data:     binary.LittleEndian.Uint32(chunk[a+16:]),
+		}
+		if int(nameIdx) < len(resMap) {
+			attr.ResID = resMap[nameIdx]
+		}
+		elem.Attrs = append(elem.Attrs, attr)
+	}
+
+	return elem, nil
+}
+
+// stringPool is a lazily decoded ResStringPool chunk.
+// resources.arsc pools can hold 100k+ strings, so entries are decoded on demand.
+type stringPool struct {
+	chunk   []byte
+	offsets []byte
+	start   int
+	count   int
+	utf8    bool
+}
+
+// newStringPool validates a ResStringPool chunk header.
+func newStringPool(chunk []byte) (*stringPool, error) {
+	if len(chunk) < 28 {
+		return nil, fmt.Errorf("truncated string pool")
+	}
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	count := int(binary.LittleEndian.Uint32(chunk[8:]))
+	flags := binary.LittleEndian.Uint32(chunk[16:])
+
+	if headerSize+count*4 > len(chunk) {
+		return nil, fmt.Errorf("truncated string pool offsets")
+	}
+	return &stringPool{
+		chunk:   chunk,
+		offsets: chunk[headerSize : headerSize+count*4],
+		start:   int(binary.LittleEndian.Uint32(chunk[20:])),
+		count:   count,
+		utf8:    flags&stringPoolUTF8Flag != 0,
+	}, nil
+}
+
+// get returns the string at idx, or "" for missing indices.
+func (p *stringPool) get(idx uint32) string {
+	if p == nil || idx == axmlNoIndex || int(idx) >= p.count {
+		return ""
+	}
+	off := p.start + int(binary.LittleEndian.Uint32(p.offsets[idx*4:]))
+	if off >= len(p.chunk) {
+		return ""
+	}
+	if p.utf8 {
+		return decodeUTF8PoolString(p.chunk[off:])
+	}
+	return decodeUTF16PoolString(p.chunk[off:])
+}
+
+// parseStringPool decodes a ResStringPool chunk into Go strings.
+func parseStringPool(chunk []byte) ([]string, error) {
+	p, err := newStringPool(chunk)
+	if err != nil {
+		return nil, err
+	}
+	pool := make([]string, p.count)
+	for i := range pool {
+		pool[i] = p.get(uint32(i))
+	}
+	return pool, nil
+}
+
+// decodeUTF8PoolString decodes a length-prefixed UTF-8 pool entry.
+func decodeUTF8PoolString(b []byte) string {
+	// UTF-16 length, then UTF-8 byte length; each is 1 or 2 bytes
+	_, n := poolLength8(b)
+	if n >= len(b) {
+		return ""
+	}
+	size, m := poolLength8(b[n:])
+	start := n + m
+	if start+size > len(b) {
+		return ""
+	}
+	return string(b[start : start+size])
+}
+
+// poolLength8 reads a 1- or 2-byte UTF-8 pool length.
+func poolLength8(b []byte) (length, n int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	if b[0]&0x80 != 0 && len(b) > 1 {
+		return int(b[0]&0x7f)<<8 | int(b[1]), 2
+	}
+	return int(b[0]), 1
+}
+
+// decodeUTF16PoolString decodes a length-prefixed UTF-16 pool entry.
+func decodeUTF16PoolString(b []byte) string {
+	if len(b) < 2 {
+		return ""
+	}
+	length := int(binary.LittleEndian.Uint16(b))
+	start := 2
+	if length&0x8000 != 0 && len(b) >= 4 {
+		length = (length&0x7fff)<<16 | int(binary.LittleEndian.Uint16(b[2:]))
+		start = 4
+	}
+	if start+length*2 > len(b) {
+		return ""
+	}
+	units := make([]uint16, length)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[start+i*2:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// poolString returns the pool entry at idx, or "" for missing indices.
+func poolString(pool []string, idx uint32) string {
+	if idx == axmlNoIndex || int(idx) >= len(pool) {
+		return ""
+	}
+	return pool[idx]
+}
+
+// resColor converts a Res_value color literal to a color.
+// #RGB and #ARGB literals are stored already expanded to 8 bits per channel.
+func resColor(dataType uint8, data uint32) (color.NRGBA, bool) {
+	switch dataType {
+	case resTypeARGB8, resTypeRGB8, resTypeARGB4, resTypeRGB4:
+		a := uint8(data >> 24)
+		if dataType == resTypeRGB8 || dataType == resTypeRGB4 {
+			a = 0xff
+		}
+		return color.NRGBA{R: uint8(data >> 16), G: uint8(data >> 8), B: uint8(data), A: a}, true
+	}
+	return color.NRGBA{}, false
+}
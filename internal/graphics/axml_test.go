@@ -0,0 +1,205 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+	"unicode/utf16"
+)
+
+// noIndex is axmlNoIndex typed for le.
+const noIndex = uint32(axmlNoIndex)
+
+// le appends little-endian values of any fixed size to b.
+func le(b []byte, values ...any) []byte {
+	buf := bytes.NewBuffer(b)
+	for _, v := range values {
+		_ = binary.Write(buf, binary.LittleEndian, v)
+	}
+	return buf.Bytes()
+}
+
+// resChunk builds a resource chunk of typ. header holds the header fields after the
+// common type, header size and size fields.
+func resChunk(typ uint16, header, body []byte) []byte {
+	headerSize := 8 + len(header)
+	b := le(nil, typ, uint16(headerSize), uint32(headerSize+len(body)))
+	return append(append(b, header...), body...)
+}
+
+// stringPoolChunk builds a ResStringPool chunk of strs, UTF-8 or UTF-16 encoded.
+func stringPoolChunk(strs []string, utf8 bool) []byte {
+	var offsets, data []byte
+	for _, s := range strs {
+		offsets = le(offsets, uint32(len(data)))
+		if utf8 {
+			data = append(data, byte(len([]rune(s))), byte(len(s)))
+			data = append(append(data, s...), 0)
+		} else {
+			units := utf16.Encode([]rune(s))
+			data = le(data, uint16(len(units)), units, uint16(0))
+		}
+	}
+	var flags uint32
+	if utf8 {
+		flags = stringPoolUTF8Flag
+	}
+	header := le(nil, uint32(len(strs)), uint32(0), flags, uint32(28+len(offsets)), uint32(0))
+	return resChunk(resStringPoolType, header, append(offsets, data...))
+}
+
+// testAttr is an attribute of a start element built by startElementChunk.
+type testAttr struct {
+	name     uint32
+	raw      uint32
+	dataType uint8
+	data     uint32
+}
+
+// startElementChunk builds a RES_XML_START_ELEMENT chunk with attributes.
+func startElementChunk(name uint32, attrs ...testAttr) []byte {
+	body := le(nil, noIndex, name, uint16(20), uint16(20), uint16(len(attrs)), uint16(0), uint16(0), uint16(0))
+	for _, a := range attrs {
+		body = le(body, noIndex, a.name, a.raw, uint16(8), uint8(0), a.dataType, a.data)
+	}
+	return resChunk(resXMLStartElement, le(nil, uint32(1), noIndex), body)
+}
+
+// endElementChunk builds a RES_XML_END_ELEMENT chunk.
+func endElementChunk(name uint32) []byte {
+	return resChunk(resXMLEndElement, le(nil, uint32(1), noIndex), le(nil, noIndex, name))
+}
+
+// adaptiveIconAXML builds the binary XML of an adaptive icon with a color background
+// and a drawable foreground. Attribute names are stripped, as in obfuscated APKs, so
+// they are only known by their framework resource IDs.
+func adaptiveIconAXML(utf8 bool) []byte {
+	pool := stringPoolChunk([]string{"", "", "adaptive-icon", "background", "foreground"}, utf8)
+	resMap := resChunk(resXMLResourceMap, nil, le(nil, uint32(attrColor), uint32(attrDrawable)))
+	var body []byte
+	for _, c := range [][]byte{
+		pool,
+		resMap,
+		startElementChunk(2),
+		startElementChunk(3, testAttr{name: 0, raw: noIndex, dataType: resTypeRGB8, data: 0x00336699}),
+		endElementChunk(3),
+		startElementChunk(4, testAttr{name: 1, raw: noIndex, dataType: resTypeReference, data: 0x7f080042}),
+		endElementChunk(4),
+		endElementChunk(2),
+	} {
+		body = append(body, c...)
+	}
+	return resChunk(resXMLType, nil, body)
+}
+
+func TestParseAXML(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		utf8 bool
+	}{
+		{"utf16 pool", false},
+		{"utf8 pool", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := ParseAXML(adaptiveIconAXML(tt.utf8))
+			if err != nil {
+				t.Fatalf("ParseAXML: %v", err)
+			}
+			if root.Name != "adaptive-icon" || len(root.Children) != 2 {
+				t.Fatalf("root = %q with %d children, want adaptive-icon with 2", root.Name, len(root.Children))
+			}
+
+			bg := root.Child("background")
+			if bg == nil {
+				t.Fatal("no background element")
+			}
+			attr, ok := bg.Attr("color", attrColor)
+			if !ok {
+				t.Fatal("background color not found by resource ID")
+			}
+			if c, ok := attr.Color(); !ok || c != (color.NRGBA{R: 0x33, G: 0x66, B: 0x99, A: 0xff}) {
+				t.Errorf("background color = %v, %v", c, ok)
+			}
+
+			fg := root.Child("foreground")
+			if fg == nil {
+				t.Fatal("no foreground element")
+			}
+			attr, ok = fg.Attr("drawable", attrDrawable)
+			if !ok || !attr.IsReference() || attr.Data != 0x7f080042 {
+				t.Errorf("foreground drawable = %+v, %v; want reference to 0x7f080042", attr, ok)
+			}
+			if _, ok := fg.Attr("color", attrColor); ok {
+				t.Error("foreground has a color attribute")
+			}
+		})
+	}
+}
+
+func TestParseAXMLRejects(t *testing.T) {
+	valid := adaptiveIconAXML(false)
+	oversized := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(oversized[8+4:], 1<<30)
+
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"short header", valid[:4]},
+		{"not binary XML", []byte("<adaptive-icon/>")},
+		{"header only", valid[:8]},
+		{"chunk past end", oversized},
+		{"no elements", resChunk(resXMLType, nil, stringPoolChunk([]string{"a"}, false))},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if root, err := ParseAXML(tt.data); err == nil {
+				t.Errorf("ParseAXML = %+v, want error", root)
+			}
+		})
+	}
+}
+
+func TestParseAXMLTruncated(t *testing.T) {
+	valid := adaptiveIconAXML(true)
+	for n := range valid {
+		// Must fail or return a partial tree, never panic
+		_, _ = ParseAXML(valid[:n])
+	}
+
+	// Corrupt the string pool's count and string start so offsets point past the chunk
+	corrupt := append([]byte(nil), valid...)
+	pool := 8
+	binary.LittleEndian.PutUint32(corrupt[pool+8:], 0xffff)
+	_, _ = ParseAXML(corrupt)
+	binary.LittleEndian.PutUint32(corrupt[pool+8:], 5)
+	binary.LittleEndian.PutUint32(corrupt[pool+20:], 0xffffff)
+	if root, err := ParseAXML(corrupt); err == nil && root.Name != "" {
+		t.Errorf("root name = %q from out-of-range strings, want empty", root.Name)
+	}
+}
+
+func TestResColor(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		dataType uint8
+		data     uint32
+		want     color.NRGBA
+		ok       bool
+	}{
+		{"argb8", resTypeARGB8, 0x80112233, color.NRGBA{R: 0x11, G: 0x22, B: 0x33, A: 0x80}, true},
+		{"rgb8 is opaque", resTypeRGB8, 0x00112233, color.NRGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xff}, true},
+		{"argb4 expanded", resTypeARGB4, 0xffaabbcc, color.NRGBA{R: 0xaa, G: 0xbb, B: 0xcc, A: 0xff}, true},
+		{"rgb4 is opaque", resTypeRGB4, 0x00aabbcc, color.NRGBA{R: 0xaa, G: 0xbb, B: 0xcc, A: 0xff}, true},
+		{"reference", resTypeReference, 0x7f010001, color.NRGBA{}, false},
+		{"string", resTypeString, 3, color.NRGBA{}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resColor(tt.dataType, tt.data)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("resColor(%#x, %#x) = %v, %v; want %v, %v", tt.dataType, tt.data, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
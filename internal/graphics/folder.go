@@ -0,0 +1,48 @@
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Folder icon geometry in pixels: a square tile holding a 2x2 mosaic of child icons.
+const (
+	folderIconSize = 256
+	folderGap      = 24 // Margin around and between the child icons
+)
+
+// folderTileColor is the translucent tile behind the mosaic, visible on light and dark backgrounds.
+var folderTileColor = color.NRGBA{R: 128, G: 128, B: 128, A: 96}
+
+// NewFolderIcon composes the first four child icons into a 2x2 mosaic on a rounded tile.
+// Missing children leave their quadrant empty.
+func NewFolderIcon(children []image.Image) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, folderIconSize, folderIconSize))
+	mask := ShapeMask(ShapeRoundedSquare, folderIconSize)
+	draw.DrawMask(dst, dst.Bounds(), image.NewUniform(folderTileColor), image.Point{}, mask, image.Point{}, draw.Src)
+
+	tile := (folderIconSize - 3*folderGap) / 2
+	for i, child := range children {
+		if i == 4 {
+			break
+		}
+		if child == nil {
+			continue
+		}
+
+		var scaled image.Image
+		if svg, ok := child.(*SVGIcon); ok {
+			scaled = svg.Rasterize(tile, tile)
+		} else {
+			scaled = ScaleImageAspectFit(child, tile, tile)
+		}
+
+		// Center each icon in its quadrant
+		b := scaled.Bounds()
+		x := folderGap + (i%2)*(tile+folderGap) + (tile-b.Dx())/2
+		y := folderGap + (i/2)*(tile+folderGap) + (tile-b.Dy())/2
+		draw.Draw(dst, image.Rect(x, y, x+b.Dx(), y+b.Dy()), scaled, b.Min, draw.Over)
+	}
+	return dst
+}
@@ -2,134 +2,119 @@ package graphics
 
 import (
 	"fmt"
+	"image"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
-	"time"
 )
 
-// Cache TTL for icon path cache (7 days)
-const iconPathCacheTTL = 7 * 24 * time.Hour
-
-// getCachedIconPath returns the path for a cached icon PNG (Tier 1 cache).
+// getCachedIconPath returns the path for a cached bitmap icon PNG (Tier 1 cache).
+// Bitmaps live apart from the icons/<pkg>.png files of versions that cached every icon
+// flattened, so those are ignored and apps with adaptive icons get extracted again.
 func getCachedIconPath(pkg string) string {
 	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".config", "tooie-shelf", "icons", pkg+".png")
+	return filepath.Join(home, ".config", "tooie-shelf", "icons", "apk", pkg+".png")
 }
 
-// getIconPathCachePath returns the path for cached icon resource path (Tier 2 cache).
-func getIconPathCachePath(pkg string) string {
+// getCachedPackIconPath returns the path for an app icon extracted from an icon pack.
+// Packs map each activity to its own drawable, so icons are cached per activity.
+func getCachedPackIconPath(pack, pkg, activity string) string {
 	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".config", "tooie-shelf", "icon-paths", pkg+".txt")
-}
-
-// getCachedIconPathWithTTL returns cached icon path if valid, or empty if expired/missing.
-func getCachedIconPathWithTTL(pkg string) string {
-	cachePath := getIconPathCachePath(pkg)
-
-	info, err := os.Stat(cachePath)
-	if err != nil {
-		return ""
-	}
-
-	// Check if cache is expired
-	if time.Since(info.ModTime()) > iconPathCacheTTL {
-		return ""
+	name := pkg
+	if activity != "" {
+		if strings.HasPrefix(activity, ".") {
+			activity = pkg + activity
+		}
+		name += "@" + activity
 	}
+	return filepath.Join(home, ".config", "tooie-shelf", "icons", "packs", pack, name+".png")
+}
 
-	data, err := os.ReadFile(cachePath)
-	if err != nil {
-		return ""
-	}
+// adaptiveLayerNames are the file suffixes used to cache adaptive icon layers.
+var adaptiveLayerNames = []string{"background", "foreground", "monochrome"}
 
-	return strings.TrimSpace(string(data))
+// getCachedAdaptiveLayerPath returns the path for a cached adaptive icon layer (Tier 1 cache).
+func getCachedAdaptiveLayerPath(pkg, layer string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "tooie-shelf", "icons", "adaptive", pkg+"."+layer+".png")
 }
 
-// saveIconPathCache saves the icon resource path to cache.
-func saveIconPathCache(pkg, iconPath string) error {
-	cachePath := getIconPathCachePath(pkg)
-	_ = os.MkdirAll(filepath.Dir(cachePath), 0755)
-	return os.WriteFile(cachePath, []byte(iconPath), 0644)
+// loadCachedAdaptiveIcon loads cached adaptive icon layers. The monochrome layer is optional.
+func loadCachedAdaptiveIcon(pkg string) (*AdaptiveIcon, error) {
+	layers := make(map[string]image.Image)
+	for _, layer := range adaptiveLayerNames {
+		img, err := LoadImage(getCachedAdaptiveLayerPath(pkg, layer))
+		if err != nil {
+			if layer == "monochrome" {
+				continue
+			}
+			return nil, err
+		}
+		layers[layer] = img
+	}
+	return &AdaptiveIcon{
+		Background: layers["background"],
+		Foreground: layers["foreground"],
+		Monochrome: layers["monochrome"],
+	}, nil
 }
 
-// getIconPathViaADB uses rish to query icon path via pm dump (avoids aapt2 dependency).
-// This is expensive, so results are cached aggressively.
-func getIconPathViaADB(pkg string) (string, error) {
-	// Check Tier 2 cache first
-	if cached := getCachedIconPathWithTTL(pkg); cached != "" {
-		return cached, nil
+// saveAdaptiveIconCache saves adaptive icon layers to the Tier 1 cache.
+func saveAdaptiveIconCache(pkg string, icon *AdaptiveIcon) error {
+	layers := map[string]image.Image{
+		"background": icon.Background,
+		"foreground": icon.Foreground,
+		"monochrome": icon.Monochrome,
 	}
-
-	// Query via rish (adb shell)
-	// rish is at $HOME/.rish/rish
-	home, _ := os.UserHomeDir()
-	rishPath := filepath.Join(home, ".rish", "rish")
-
-	// Check if rish exists
-	if _, err := os.Stat(rishPath); err != nil {
-		return "", fmt.Errorf("rish not found at %s", rishPath)
+	for _, layer := range adaptiveLayerNames {
+		if layers[layer] == nil {
+			continue
+		}
+		path := getCachedAdaptiveLayerPath(pkg, layer)
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		if err := SaveImage(layers[layer], path); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// Run pm dump via rish
-	cmd := exec.Command(rishPath, "-c", fmt.Sprintf("pm dump %s", pkg))
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("rish pm dump failed: %w", err)
+// FileStamp identifies a file's current contents by path, size and modification time
+// without reading it, or returns "" if the file doesn't exist.
+func FileStamp(path string) string {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return ""
 	}
+	return fmt.Sprintf("%s@%d.%d", path, info.Size(), info.ModTime().UnixNano())
+}
 
-	// Parse pm dump output for icon resource
-	iconPath := parseIconFromPMDump(string(output))
-	if iconPath == "" {
-		return "", fmt.Errorf("no icon found in pm dump output")
+// URLIconStamp returns the FileStamp of a URL icon's cached download, or "" before it
+// is downloaded. still is false when the download is an animation.
+func URLIconStamp(url string) (stamp string, still bool) {
+	cachePath := getURLIconCachePath(url)
+	if stamp := FileStamp(strings.TrimSuffix(cachePath, ".png") + ".svg"); stamp != "" {
+		return stamp, true
 	}
-
-	// Save to Tier 2 cache
-	_ = saveIconPathCache(pkg, iconPath)
-
-	return iconPath, nil
+	if stamp := FileStamp(strings.TrimSuffix(cachePath, ".png") + ".anim"); stamp != "" {
+		return stamp, false
+	}
+	return FileStamp(cachePath), true
 }
 
-// parseIconFromPMDump extracts icon resource path from pm dump output.
-func parseIconFromPMDump(output string) string {
-	lines := strings.Split(output, "\n")
-
-	// Look for icon resource in various formats
-	// Primary: "icon=res/mipmap-xxxhdpi/ic_launcher.png"
-	// Alternative: "applicationIcon=..." or in activity sections
-
-	var bestIcon string
-	highestRes := 0
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		// Look for icon= pattern
-		if strings.Contains(line, "icon=") {
-			parts := strings.Split(line, "icon=")
-			if len(parts) >= 2 {
-				iconPart := strings.TrimSpace(parts[1])
-				// Extract just the resource path
-				if idx := strings.Index(iconPart, " "); idx != -1 {
-					iconPart = iconPart[:idx]
-				}
+// PackIconStamp returns the FileStamp of an app's icon extracted from an icon pack,
+// or "" before it is extracted.
+func PackIconStamp(pack, pkg, activity string) string {
+	return FileStamp(getCachedPackIconPath(pack, pkg, activity))
+}
 
-				// Check if it's a PNG (prefer over XML)
-				if strings.HasSuffix(iconPart, ".png") {
-					res := extractResolution(iconPart)
-					if res > highestRes {
-						highestRes = res
-						bestIcon = iconPart
-					}
-				} else if strings.HasSuffix(iconPart, ".xml") && bestIcon == "" {
-					// XML fallback - try PNG version
-					bestIcon = strings.TrimSuffix(iconPart, ".xml") + ".png"
-				}
-			}
-		}
+// APKIconStamp returns the FileStamp of an app's Tier 1 cached icon, preferring its
+// adaptive layers, or "" before it is extracted.
+func APKIconStamp(pkg string) string {
+	if stamp := FileStamp(getCachedAdaptiveLayerPath(pkg, "foreground")); stamp != "" {
+		return stamp
 	}
-
-	return bestIcon
+	return FileStamp(getCachedIconPath(pkg))
 }
 
 // extractResolution extracts DPI resolution from resource path.
@@ -0,0 +1,229 @@
+package graphics
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// appfilterLocations are where ADW/Nova-compatible icon packs ship their component map.
+// The compiled resource is preferred; many packs also keep a plain-text copy in assets.
+var appfilterLocations = []string{
+	"res/xml/appfilter.xml",
+	"assets/appfilter.xml",
+}
+
+// IconPack maps app components to drawables of an installed icon pack.
+type IconPack struct {
+	Package    string
+	components map[string]string // "pkg/activity" -> drawable name
+	packages   map[string]string // "pkg" -> first drawable listed for the package
+	apkPaths   []string
+}
+
+// iconPackEntry holds one pack, loaded by a single caller at a time.
+type iconPackEntry struct {
+	sem  chan struct{} // Held while loading
+	pack *IconPack     // nil until loaded
+}
+
+var (
+	iconPacksMu sync.Mutex
+	iconPacks   = make(map[string]*iconPackEntry) // By pack package
+)
+
+// LoadIconPack opens an installed icon pack and parses its appfilter.xml.
+// Packs are parsed once per process; a failed load is retried by the next caller.
+// Callers waiting on another's load give up when ctx is done.
+func LoadIconPack(ctx context.Context, pack string) (*IconPack, error) {
+	iconPacksMu.Lock()
+	e, ok := iconPacks[pack]
+	if !ok {
+		e = &iconPackEntry{sem: make(chan struct{}, 1)}
+		iconPacks[pack] = e
+	}
+	iconPacksMu.Unlock()
+
+	select {
+	case e.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-e.sem }()
+
+	if e.pack != nil {
+		return e.pack, nil
+	}
+	p, err := loadIconPack(ctx, pack)
+	if err != nil {
+		return nil, err
+	}
+	e.pack = p
+	return p, nil
+}
+
+func loadIconPack(ctx context.Context, pack string) (*IconPack, error) {
+	apkPaths, err := getAPKPaths(ctx, pack)
+	if err != nil {
+		return nil, err
+	}
+	files, err := openAPKFiles(apkPaths)
+	if err != nil {
+		return nil, err
+	}
+	defer files.Close()
+
+	p := &IconPack{
+		Package:    pack,
+		components: make(map[string]string),
+		packages:   make(map[string]string),
+		apkPaths:   apkPaths,
+	}
+	for _, loc := range appfilterLocations {
+		data, err := files.read(loc)
+		if err != nil {
+			continue
+		}
+		items, err := parseAppfilter(data)
+		if err != nil {
+			logIconExtraction(pack, "Failed to parse appfilter", loc, err.Error())
+			continue
+		}
+		for _, item := range items {
+			p.add(item.Component, item.Drawable)
+		}
+		logIconExtraction(pack, "Loaded icon pack appfilter", loc, fmt.Sprintf("%d components", len(items)))
+		return p, nil
+	}
+	return nil, fmt.Errorf("no appfilter.xml in icon pack %s", pack)
+}
+
+// add records one component mapping.
+func (p *IconPack) add(component, drawable string) {
+	pkg, _, _ := strings.Cut(component, "/")
+	p.components[component] = drawable
+	if _, ok := p.packages[pkg]; !ok {
+		p.packages[pkg] = drawable
+	}
+}
+
+// appfilterItem maps one app component ("pkg/activity") to a drawable name.
+type appfilterItem struct {
+	Component string
+	Drawable  string
+}
+
+// parseAppfilter reads <item component="ComponentInfo{pkg/activity}" drawable="name"/>
+// entries from a compiled or plain-text appfilter.xml, in document order.
+func parseAppfilter(data []byte) ([]appfilterItem, error) {
+	var items []appfilterItem
+	add := func(component, drawable string) {
+		component = strings.TrimSpace(component)
+		if !strings.HasPrefix(component, "ComponentInfo{") || drawable == "" {
+			return
+		}
+		component = strings.TrimSuffix(strings.TrimPrefix(component, "ComponentInfo{"), "}")
+		pkg, activity, _ := strings.Cut(component, "/")
+		// Relative activity names (".MainActivity") are relative to the package
+		if strings.HasPrefix(activity, ".") {
+			activity = pkg + activity
+		}
+		items = append(items, appfilterItem{Component: pkg + "/" + activity, Drawable: drawable})
+	}
+
+	if root, err := ParseAXML(data); err == nil {
+		for _, item := range root.Children {
+			if item.Name != "item" {
+				continue
+			}
+			component, _ := item.Attr("component", 0)
+			drawable, _ := item.Attr("drawable", 0)
+			add(component.Raw, drawable.Raw)
+		}
+		return items, nil
+	}
+
+	var doc struct {
+		Items []struct {
+			Component string `xml:"component,attr"`
+			Drawable  string `xml:"drawable,attr"`
+		} `xml:"item"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for _, item := range doc.Items {
+		add(item.Component, item.Drawable)
+	}
+	return items, nil
+}
+
+// Drawable returns the drawable name for an app, matching the exact component first
+// and falling back to any component of the same package.
+func (p *IconPack) Drawable(pkg, activity string) (string, bool) {
+	if activity != "" {
+		if strings.HasPrefix(activity, ".") {
+			activity = pkg + activity
+		}
+		if d, ok := p.components[pkg+"/"+activity]; ok {
+			return d, true
+		}
+	}
+	d, ok := p.packages[pkg]
+	return d, ok
+}
+
+// Icon extracts the pack's icon for an app from the pack APK.
+func (p *IconPack) Icon(pkg, activity string) (image.Image, error) {
+	drawable, ok := p.Drawable(pkg, activity)
+	if !ok {
+		return nil, fmt.Errorf("%s has no icon for %s", p.Package, pkg)
+	}
+
+	files, err := openAPKFiles(p.apkPaths)
+	if err != nil {
+		return nil, err
+	}
+	defer files.Close()
+
+	name := files.findBestBitmap(drawable)
+	if name == "" {
+		return nil, fmt.Errorf("drawable %s not found in %s", drawable, p.Package)
+	}
+	return files.decodeImage(name)
+}
+
+// ExtractIconPackIcon returns an app's icon from an installed icon pack.
+// Extracted icons are cached per pack and app activity.
+func ExtractIconPackIcon(ctx context.Context, pack, pkg, activity string) (image.Image, error) {
+	if pack == "" || pkg == "" {
+		return nil, fmt.Errorf("icon pack and package are required")
+	}
+
+	cachePath := getCachedPackIconPath(pack, pkg, activity)
+	if cached, err := LoadImage(cachePath); err == nil {
+		logIconExtraction(pkg, "Icon pack cache hit", cachePath)
+		return cached, nil
+	}
+
+	p, err := LoadIconPack(ctx, pack)
+	if err != nil {
+		logIconExtraction(pkg, "Icon pack unavailable", pack, err.Error())
+		return nil, err
+	}
+	img, err := p.Icon(pkg, activity)
+	if err != nil {
+		logIconExtraction(pkg, "No icon pack icon", err.Error())
+		return nil, err
+	}
+	logIconExtraction(pkg, "Icon extracted from icon pack", pack)
+
+	_ = os.MkdirAll(filepath.Dir(cachePath), 0755)
+	_ = SaveImage(img, cachePath)
+	return img, nil
+}
@@ -0,0 +1,45 @@
+package graphics
+
+import "testing"
+
+func TestIconPackDrawable(t *testing.T) {
+	items, err := parseAppfilter([]byte(`<resources>
+		<item component="ComponentInfo{com.example/com.example.Main}" drawable="example_main"/>
+		<item component="ComponentInfo{com.example/.Settings}" drawable="example_settings"/>
+		<item component="ComponentInfo{com.other/com.other.A}" drawable="other_a"/>
+		<item component="ComponentInfo{com.other/com.other.B}" drawable="other_b"/>
+		<item component="ComponentInfo{com.other/com.other.C}" drawable="other_c"/>
+		<item component=":LAUNCHER_ACTION_APP_DRAWER" drawable="drawer"/>
+		<item component="ComponentInfo{com.empty/com.empty.Main}" drawable=""/>
+	</resources>`))
+	if err != nil {
+		t.Fatalf("parseAppfilter: %v", err)
+	}
+
+	p := &IconPack{components: make(map[string]string), packages: make(map[string]string)}
+	for _, item := range items {
+		p.add(item.Component, item.Drawable)
+	}
+
+	for _, tt := range []struct {
+		name          string
+		pkg, activity string
+		want          string
+		ok            bool
+	}{
+		{"exact component", "com.example", "com.example.Main", "example_main", true},
+		{"relative activity in appfilter", "com.example", "com.example.Settings", "example_settings", true},
+		{"relative activity in lookup", "com.example", ".Settings", "example_settings", true},
+		{"unknown activity falls back to first listed", "com.other", "com.other.Z", "other_a", true},
+		{"no activity falls back to first listed", "com.other", "", "other_a", true},
+		{"empty drawable skipped", "com.empty", "com.empty.Main", "", false},
+		{"unknown package", "com.missing", "", "", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := p.Drawable(tt.pkg, tt.activity)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Drawable(%q, %q) = %q, %v; want %q, %v", tt.pkg, tt.activity, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
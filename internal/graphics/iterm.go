@@ -0,0 +1,55 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+
+	"tooie-shelf/internal/sys"
+)
+
+// ITermRenderer renders images using the iTerm2 inline image protocol (OSC 1337).
+// Supported by iTerm2, WezTerm, mintty and a few others.
+type ITermRenderer struct{}
+
+// Name returns the protocol name.
+func (ITermRenderer) Name() string {
+	return ProtocolITerm
+}
+
+// Render converts an image to an OSC 1337 File= sequence with a PNG payload.
+// The image is pre-scaled to fit the requested cells and its exact pixel size
+// is passed along so the terminal does not rescale it.
+func (ITermRenderer) Render(src image.Image, widthCells, heightCells int, cellPx sys.CellDim, style IconStyle) Payload {
+	scaled := prepareIcon(src, widthCells, heightCells, cellPx, style)
+	if scaled == nil {
+		return Payload{}
+	}
+	bounds := scaled.Bounds()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaled); err != nil {
+		return Payload{}
+	}
+
+	data := fmt.Sprintf("\x1b]1337;File=inline=1;size=%d;width=%dpx;height=%dpx;preserveAspectRatio=1;doNotMoveCursor=1:%s\x07",
+		buf.Len(), bounds.Dx(), bounds.Dy(), base64.StdEncoding.EncodeToString(buf.Bytes()))
+
+	return Payload{
+		Data:   data,
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+	}
+}
+
+// Delete is a no-op: inline images are cell content and get overwritten by redraws.
+func (ITermRenderer) Delete(p Payload) string {
+	return ""
+}
+
+// ClearAll is a no-op for inline images.
+func (ITermRenderer) ClearAll() string {
+	return ""
+}
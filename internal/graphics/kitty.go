@@ -0,0 +1,113 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/png"
+	"strings"
+
+	"tooie-shelf/internal/sys"
+)
+
+// kittyChunkSize is the maximum base64 payload per escape sequence allowed by the protocol.
+const kittyChunkSize = 4096
+
+// kittyZIndex places images below text (but above default cell backgrounds),
+// so overlay labels drawn after an icon stay readable.
+const kittyZIndex = -1
+
+// kittyWallpaperZIndex keeps the wallpaper below icons drawn at kittyZIndex.
+const kittyWallpaperZIndex = kittyZIndex - 1
+
+// KittyRenderer renders images using the kitty graphics protocol.
+// Images are sent as PNG so alpha is preserved, and each one gets an ID derived
+// from its content so it can be deleted individually and cached across runs.
+type KittyRenderer struct{}
+
+// Name returns the protocol name.
+func (r *KittyRenderer) Name() string {
+	return ProtocolKitty
+}
+
+// Render converts an image to a kitty graphics transmit-and-display sequence.
+func (r *KittyRenderer) Render(src image.Image, widthCells, heightCells int, cellPx sys.CellDim, style IconStyle) Payload {
+	scaled := prepareIcon(src, widthCells, heightCells, cellPx, style)
+	if scaled == nil {
+		return Payload{}
+	}
+	bounds := scaled.Bounds()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaled); err != nil {
+		return Payload{}
+	}
+
+	z := kittyZIndex
+	if _, ok := src.(*Wallpaper); ok {
+		z = kittyWallpaperZIndex
+	}
+
+	id := kittyImageID(buf.Bytes())
+	return Payload{
+		Data:   encodeKitty(buf.Bytes(), id, z),
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+		ID:     id,
+	}
+}
+
+// Delete returns the sequence that deletes the image and frees its data.
+func (r *KittyRenderer) Delete(p Payload) string {
+	if p.ID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", p.ID)
+}
+
+// ClearAll returns the sequence that deletes all images and frees their data.
+func (r *KittyRenderer) ClearAll() string {
+	return "\x1b_Ga=d,d=A,q=2\x1b\\"
+}
+
+// kittyImageID derives a stable, non-zero image ID from the encoded image data.
+func kittyImageID(data []byte) uint32 {
+	h := fnv.New32a()
+	h.Write(data)
+	id := h.Sum32()
+	if id == 0 {
+		id = 1
+	}
+	return id
+}
+
+// encodeKitty builds a chunked kitty transmit-and-display sequence for PNG data.
+// C=1 keeps the cursor in place, q=2 suppresses terminal responses and z puts the image under text.
+func encodeKitty(pngData []byte, id uint32, z int) string {
+	encoded := base64.StdEncoding.EncodeToString(pngData)
+
+	var b strings.Builder
+	first := true
+	for len(encoded) > 0 {
+		chunk := encoded
+		if len(chunk) > kittyChunkSize {
+			chunk = chunk[:kittyChunkSize]
+		}
+		encoded = encoded[len(chunk):]
+
+		more := 0
+		if len(encoded) > 0 {
+			more = 1
+		}
+
+		if first {
+			fmt.Fprintf(&b, "\x1b_Ga=T,f=100,i=%d,q=2,C=1,z=%d,m=%d;%s\x1b\\", id, z, more, chunk)
+			first = false
+		} else {
+			fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"sync"
+)
+
+// LazyIcon is an icon that loads on first use. Payloads are cached on disk by icon
+// source (see PayloadCacheKey), so an icon whose payloads are all cached never loads.
+type LazyIcon struct {
+	load func() image.Image
+	once sync.Once
+	img  image.Image
+}
+
+// NewLazyIcon returns an icon loaded by load when its pixels are first needed.
+func NewLazyIcon(load func() image.Image) *LazyIcon {
+	return &LazyIcon{load: load}
+}
+
+// Image loads the icon if it hasn't been yet and returns it.
+func (l *LazyIcon) Image() image.Image {
+	l.once.Do(func() {
+		l.img = l.load()
+		if l.img == nil {
+			l.img = CreatePlaceholder(64, 64)
+		}
+	})
+	return l.img
+}
+
+// ColorModel implements image.Image.
+func (l *LazyIcon) ColorModel() color.Model { return l.Image().ColorModel() }
+
+// Bounds implements image.Image.
+func (l *LazyIcon) Bounds() image.Rectangle { return l.Image().Bounds() }
+
+// At implements image.Image.
+func (l *LazyIcon) At(x, y int) color.Color { return l.Image().At(x, y) }
+
+// Unwrap returns the loaded image behind a LazyIcon, or img itself for any other image.
+func Unwrap(img image.Image) image.Image {
+	if l, ok := img.(*LazyIcon); ok {
+		return l.Image()
+	}
+	return img
+}
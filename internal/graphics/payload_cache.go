@@ -0,0 +1,154 @@
+package graphics
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tooie-shelf/internal/sys"
+)
+
+// payloadCacheTTL is how long an unused payload stays on disk before being pruned.
+const payloadCacheTTL = 30 * 24 * time.Hour
+
+// payloadCacheDir returns the directory for encoded payloads.
+// Uses $XDG_CACHE_HOME/tooie-shelf/payloads if set, else ~/.config/tooie-shelf/payloads.
+func payloadCacheDir() string {
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "tooie-shelf", "payloads")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "tooie-shelf", "payloads")
+}
+
+// HashImage returns a hex digest of an image's pixels, used to detect icon changes.
+func HashImage(img image.Image) string {
+	if img == nil {
+		return ""
+	}
+
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+
+	h := sha256.New()
+	b := rgba.Bounds()
+	_ = binary.Write(h, binary.LittleEndian, [2]int32{int32(b.Dx()), int32(b.Dy())})
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		off := rgba.PixOffset(b.Min.X, y)
+		h.Write(rgba.Pix[off : off+b.Dx()*4])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// PayloadCacheKey builds the disk cache key for a rendered payload. source identifies the
+// icon's pixels without decoding them, such as its file's path and modification time.
+// Any change to the source icon, cell pixel size, target cells, scale, shape or backend yields a new key.
+func PayloadCacheKey(source string, cellPx sys.CellDim, widthCells, heightCells int, scale float64, style IconStyle, r Renderer) string {
+	raw := fmt.Sprintf("%s|%dx%d|%dx%d|%.2f|%s|%s", source, cellPx.Width, cellPx.Height, widthCells, heightCells, scale, style, rendererVariant(r))
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:16])
+}
+
+// rendererVariant identifies a renderer including any options that change its output.
+func rendererVariant(r Renderer) string {
+	switch v := r.(type) {
+	case TextRenderer:
+		if v.TrueColor {
+			return v.Name() + "-truecolor"
+		}
+	case *KittyRenderer:
+		return fmt.Sprintf("%s-z%d", v.Name(), kittyZIndex)
+	case SixelRenderer:
+		opts := v.Options.normalized()
+		variant := fmt.Sprintf("%s-%s-%s-%d", v.Name(), opts.Quantizer, opts.Dither, opts.Colors)
+		if opts.Background != nil {
+			variant += "-bg" + hexNRGBA(opts.Background)
+		}
+		return variant
+	}
+	return r.Name()
+}
+
+// LoadCachedPayload reads a payload from the disk cache.
+func LoadCachedPayload(key string) (Payload, bool) {
+	path := filepath.Join(payloadCacheDir(), key+".bin")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Payload{}, false
+	}
+
+	// Header line: "width height id"
+	r := bufio.NewReader(bytes.NewReader(data))
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return Payload{}, false
+	}
+	var p Payload
+	if _, err := fmt.Sscanf(strings.TrimSpace(header), "%d %d %d", &p.Width, &p.Height, &p.ID); err != nil {
+		return Payload{}, false
+	}
+	body, err := io.ReadAll(r)
+	if err != nil || len(body) == 0 {
+		return Payload{}, false
+	}
+	p.Data = string(body)
+
+	// Refresh modification time so pruning only drops unused entries
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	return p, true
+}
+
+// SaveCachedPayload writes a payload to the disk cache.
+func SaveCachedPayload(key string, p Payload) error {
+	if p.Data == "" {
+		return nil
+	}
+	dir := payloadCacheDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d %d %d\n", p.Width, p.Height, p.ID)
+	buf.WriteString(p.Data)
+
+	// Write atomically so a concurrent launch never reads a partial file
+	tmp := filepath.Join(dir, key+".tmp")
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, key+".bin"))
+}
+
+// PrunePayloadCache removes payloads that haven't been used within the cache TTL.
+func PrunePayloadCache() {
+	dir := payloadCacheDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if time.Since(info.ModTime()) > payloadCacheTTL {
+			_ = os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package graphics
+
+import (
+	"image/color"
+	"sort"
+)
+
+// colorCount is a unique color with its number of occurrences.
+type colorCount struct {
+	c     [3]uint8
+	count int
+}
+
+// uniqueColors counts the distinct colors in a pixel list, sorted by RGB so the same
+// pixels always quantize to the same palette.
+func uniqueColors(pixels []color.NRGBA) []colorCount {
+	counts := make(map[[3]uint8]int)
+	for _, p := range pixels {
+		counts[[3]uint8{p.R, p.G, p.B}]++
+	}
+	result := make([]colorCount, 0, len(counts))
+	for c, n := range counts {
+		result = append(result, colorCount{c: c, count: n})
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return rgbLess(result[a].c, result[b].c, 0)
+	})
+	return result
+}
+
+// quantizeMedianCut builds a palette by recursively splitting the color box
+// with the widest channel range at its weighted median.
+func quantizeMedianCut(pixels []color.NRGBA, maxColors int) []color.NRGBA {
+	colors := uniqueColors(pixels)
+	if len(colors) <= maxColors {
+		palette := make([]color.NRGBA, len(colors))
+		for i, c := range colors {
+			palette[i] = color.NRGBA{c.c[0], c.c[1], c.c[2], 255}
+		}
+		return palette
+	}
+
+	boxes := [][]colorCount{colors}
+	for len(boxes) < maxColors {
+		// Pick the box with the largest channel range weighted by population
+		bestBox, bestChannel, bestScore := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			channel, rng := widestChannel(box)
+			score := rng * boxPopulation(box)
+			if score > bestScore {
+				bestBox, bestChannel, bestScore = i, channel, score
+			}
+		}
+		if bestBox < 0 {
+			break
+		}
+
+		box := boxes[bestBox]
+		sort.Slice(box, func(a, b int) bool {
+			return rgbLess(box[a].c, box[b].c, bestChannel)
+		})
+
+		// Split at the weighted median
+		half := boxPopulation(box) / 2
+		split, acc := 1, 0
+		for i, c := range box {
+			acc += c.count
+			if acc >= half {
+				split = i + 1
+				break
+			}
+		}
+		if split >= len(box) {
+			split = len(box) - 1
+		}
+
+		boxes[bestBox] = box[:split]
+		boxes = append(boxes, box[split:])
+	}
+
+	palette := make([]color.NRGBA, len(boxes))
+	for i, box := range boxes {
+		palette[i] = averageColor(box)
+	}
+	return palette
+}
+
+// rgbLess orders colors by channel first, then by the remaining channels, so sorts
+// never leave equal channel values in an arbitrary order.
+func rgbLess(a, b [3]uint8, channel int) bool {
+	for i := 0; i < 3; i++ {
+		ch := (channel + i) % 3
+		if a[ch] != b[ch] {
+			return a[ch] < b[ch]
+		}
+	}
+	return false
+}
+
+// widestChannel returns the RGB channel with the largest value range in a box.
+func widestChannel(box []colorCount) (channel, rng int) {
+	for ch := 0; ch < 3; ch++ {
+		lo, hi := 255, 0
+		for _, c := range box {
+			v := int(c.c[ch])
+			if v < lo {
+				lo = v
+			}
+			if v > hi {
+				hi = v
+			}
+		}
+		if hi-lo > rng {
+			channel, rng = ch, hi-lo
+		}
+	}
+	return
+}
+
+// boxPopulation returns the total pixel count of a box.
+func boxPopulation(box []colorCount) int {
+	n := 0
+	for _, c := range box {
+		n += c.count
+	}
+	return n
+}
+
+// averageColor returns the population-weighted average color of a box.
+func averageColor(box []colorCount) color.NRGBA {
+	var sum [3]int
+	n := 0
+	for _, c := range box {
+		for ch := 0; ch < 3; ch++ {
+			sum[ch] += int(c.c[ch]) * c.count
+		}
+		n += c.count
+	}
+	if n == 0 {
+		return color.NRGBA{A: 255}
+	}
+	return color.NRGBA{uint8(sum[0] / n), uint8(sum[1] / n), uint8(sum[2] / n), 255}
+}
+
+// octreeNode is a node in the octree color quantizer.
+type octreeNode struct {
+	children [8]*octreeNode
+	leaf     bool
+	count    int
+	sum      [3]int
+}
+
+// quantizeOctree builds a palette with an 8-level octree, merging the deepest
+// nodes until at most maxColors leaves remain.
+func quantizeOctree(pixels []color.NRGBA, maxColors int) []color.NRGBA {
+	root := &octreeNode{}
+	levels := make([][]*octreeNode, 8)
+	leaves := 0
+
+	for _, cc := range uniqueColors(pixels) {
+		node := root
+		for level := 0; level < 8; level++ {
+			shift := 7 - level
+			idx := int(cc.c[0]>>shift&1)<<2 | int(cc.c[1]>>shift&1)<<1 | int(cc.c[2]>>shift&1)
+			if node.children[idx] == nil {
+				child := &octreeNode{leaf: level == 7}
+				node.children[idx] = child
+				if child.leaf {
+					leaves++
+				} else {
+					levels[level+1] = append(levels[level+1], child)
+				}
+			}
+			node = node.children[idx]
+		}
+		node.count += cc.count
+		for ch := 0; ch < 3; ch++ {
+			node.sum[ch] += int(cc.c[ch]) * cc.count
+		}
+	}
+
+	// Reduce from the deepest level up, folding children into their parent
+	for level := 7; level > 0 && leaves > maxColors; level-- {
+		nodes := levels[level]
+		// Merge the least populated nodes first to keep detail where it matters
+		sort.SliceStable(nodes, func(a, b int) bool {
+			return subtreeCount(nodes[a]) < subtreeCount(nodes[b])
+		})
+		for _, node := range nodes {
+			if leaves <= maxColors {
+				break
+			}
+			merged := 0
+			for i, child := range node.children {
+				if child == nil {
+					continue
+				}
+				node.count += child.count
+				for ch := 0; ch < 3; ch++ {
+					node.sum[ch] += child.sum[ch]
+				}
+				node.children[i] = nil
+				merged++
+			}
+			node.leaf = true
+			leaves -= merged - 1
+		}
+	}
+
+	var palette []color.NRGBA
+	var collect func(n *octreeNode)
+	collect = func(n *octreeNode) {
+		if n.leaf {
+			if n.count > 0 {
+				palette = append(palette, color.NRGBA{
+					uint8(n.sum[0] / n.count), uint8(n.sum[1] / n.count), uint8(n.sum[2] / n.count), 255,
+				})
+			}
+			return
+		}
+		for _, child := range n.children {
+			if child != nil {
+				collect(child)
+			}
+		}
+	}
+	collect(root)
+	return palette
+}
+
+// subtreeCount returns the number of pixels under a node.
+func subtreeCount(n *octreeNode) int {
+	if n.leaf {
+		return n.count
+	}
+	total := n.count
+	for _, child := range n.children {
+		if child != nil {
+			total += subtreeCount(child)
+		}
+	}
+	return total
+}
@@ -0,0 +1,42 @@
+package graphics
+
+import (
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+func TestQuantizeDeterministic(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	pixels := make([]color.NRGBA, 2000)
+	for i := range pixels {
+		// Few values per channel, so many colors tie on the channel a box is split on
+		pixels[i] = color.NRGBA{uint8(rng.Intn(4) * 60), uint8(rng.Intn(4) * 60), uint8(rng.Intn(4) * 60), 255}
+	}
+
+	for _, tt := range []struct {
+		name     string
+		quantize func([]color.NRGBA, int) []color.NRGBA
+	}{
+		{"median-cut", quantizeMedianCut},
+		{"octree", quantizeOctree},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.quantize(pixels, 16)
+			for run := 0; run < 20; run++ {
+				// Same pixels in another order must give the same palette
+				shuffled := append([]color.NRGBA(nil), pixels...)
+				rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+				got := tt.quantize(shuffled, 16)
+				if len(got) != len(want) {
+					t.Fatalf("run %d: %d colors, want %d", run, len(got), len(want))
+				}
+				for i := range got {
+					if got[i] != want[i] {
+						t.Fatalf("run %d: palette %v, want %v", run, got, want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package graphics
+
+import (
+	"image"
+	"os"
+	"strings"
+
+	"tooie-shelf/internal/sys"
+)
+
+// Graphics protocol names accepted by style.graphics.
+const (
+	ProtocolAuto  = "auto"
+	ProtocolSixel = "sixel"
+	ProtocolKitty = "kitty"
+	ProtocolITerm = "iterm"
+
+	// Text fallbacks for terminals without image support
+	ProtocolBlocks  = "blocks"
+	ProtocolBraille = "braille"
+)
+
+// Payload is a protocol-neutral rendered icon ready to be written at the cursor.
+type Payload struct {
+	Data   string // Escape sequence(s) that draw the image at the cursor position
+	Width  int    // Actual pixel width of rendered image
+	Height int    // Actual pixel height of rendered image
+	ID     uint32 // Protocol image ID (kitty only, 0 if unused)
+}
+
+// Renderer turns images into terminal graphics payloads for one protocol.
+type Renderer interface {
+	// Name returns the protocol name (e.g. "sixel", "kitty", "blocks").
+	Name() string
+	// Render converts an image into a payload sized for the given cell dimensions,
+	// shaped according to style.
+	Render(src image.Image, widthCells, heightCells int, cellPx sys.CellDim, style IconStyle) Payload
+	// Delete returns the escape sequence that removes a previously drawn payload, or "".
+	Delete(p Payload) string
+	// ClearAll returns the escape sequence that removes every image drawn by this renderer, or "".
+	ClearAll() string
+}
+
+// NewRenderer returns the renderer for the given protocol name.
+// "auto" (or empty) picks a protocol based on probed capabilities and the environment.
+// If sixelOpts doesn't set a color count, the terminal's XTSMGRAPHICS register count is used,
+// and the OSC 11 background color is used to blend soft edges if it is known.
+func NewRenderer(protocol string, caps sys.TerminalCaps, sixelOpts SixelOptions) Renderer {
+	if protocol == "" || protocol == ProtocolAuto {
+		protocol = DetectProtocol(caps)
+	}
+
+	switch protocol {
+	case ProtocolKitty:
+		return &KittyRenderer{}
+	case ProtocolITerm:
+		return ITermRenderer{}
+	case ProtocolBlocks:
+		return NewTextRenderer(false)
+	case ProtocolBraille:
+		return NewTextRenderer(true)
+	default:
+		if sixelOpts.Colors <= 0 && caps.ColorRegisters > 0 {
+			sixelOpts.Colors = caps.ColorRegisters
+		}
+		if sixelOpts.Background == nil {
+			sixelOpts.Background = caps.Background
+		}
+		return SixelRenderer{Options: sixelOpts.normalized()}
+	}
+}
+
+// DetectProtocol picks the best graphics protocol.
+// Answers from the startup probe win over any guess from the environment: a multiplexer
+// such as tmux answers DA1 itself, listing Sixel when it can display sixel images.
+// iTerm2-style terminals are recognized by environment since they can't be probed.
+// If the terminal answered the probe without any image support, half-block text art is used.
+func DetectProtocol(caps sys.TerminalCaps) string {
+	if caps.Responded {
+		switch {
+		case caps.Kitty:
+			return ProtocolKitty
+		case isITermEnv():
+			return ProtocolITerm
+		case caps.Sixel:
+			return ProtocolSixel
+		default:
+			return ProtocolBlocks
+		}
+	}
+	return detectProtocolFromEnv()
+}
+
+// detectProtocolFromEnv guesses the graphics protocol of a terminal that didn't answer
+// the probe, from environment variables. Only terminals known to display images get
+// them; everything else gets half-block text art, which any terminal can show.
+func detectProtocolFromEnv() string {
+	term := strings.ToLower(os.Getenv("TERM"))
+	termProgram := strings.ToLower(os.Getenv("TERM_PROGRAM"))
+
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "",
+		strings.Contains(term, "kitty"),
+		strings.Contains(term, "ghostty"),
+		termProgram == "ghostty",
+		termProgram == "wezterm":
+		return ProtocolKitty
+	case isITermEnv():
+		return ProtocolITerm
+	case os.Getenv("TERMUX_VERSION") != "",
+		strings.Contains(term, "sixel"),
+		strings.HasPrefix(term, "foot"),
+		strings.HasPrefix(term, "mlterm"):
+		return ProtocolSixel
+	}
+	return ProtocolBlocks
+}
+
+// isITermEnv reports whether the environment identifies a terminal speaking OSC 1337.
+func isITermEnv() bool {
+	termProgram := strings.ToLower(os.Getenv("TERM_PROGRAM"))
+	return termProgram == "iterm.app" ||
+		termProgram == "mintty" ||
+		os.Getenv("LC_TERMINAL") == "iTerm2" // Forwarded over SSH by iTerm2
+}
+
+// prepareIcon standardizes an icon to a shaped square and scales it to fit the target cells.
+// All icons are standardized to a square format before scaling to ensure consistent sizing.
+func prepareIcon(src image.Image, widthCells, heightCells int, cellPx sys.CellDim, style IconStyle) image.Image {
+	targetW := widthCells * cellPx.Width
+	targetH := heightCells * cellPx.Height
+
+	if targetW <= 0 || targetH <= 0 {
+		return nil
+	}
+	src = Unwrap(src)
+
+	// Wallpapers are already cropped to the grid; they are only rescaled if the grid changed
+	if wp, ok := src.(*Wallpaper); ok {
+		if b := wp.Bounds(); b.Dx() == targetW && b.Dy() == targetH {
+			return wp.RGBA
+		}
+		return NewWallpaper(wp.RGBA, targetW, targetH).RGBA
+	}
+
+	// Vector icons are rasterized straight into the fitted square, with no resampling
+	if _, ok := src.(*SVGIcon); ok {
+		return withBackdrop(StandardizeImage(src, min(targetW, targetH), style), style)
+	}
+
+	// Standardize to square format first to ensure all icons have same aspect ratio
+	// Use the larger dimension as the standard size
+	stdSize := targetW
+	if targetH > targetW {
+		stdSize = targetH
+	}
+
+	// Create standardized square icon
+	standardized := StandardizeImage(src, stdSize, style)
+
+	// Now scale to fit exactly within target dimensions
+	return withBackdrop(ScaleImageAspectFit(standardized, targetW, targetH), style)
+}
+
+// withBackdrop composites a prepared icon over the style's backdrop, if any.
+func withBackdrop(img image.Image, style IconStyle) image.Image {
+	if style.Backdrop == nil {
+		return img
+	}
+	return composeOver(img, style.Backdrop)
+}
@@ -52,7 +52,8 @@ func ScaleImageAspectFit(src image.Image, maxW, maxH int) image.Image {
 
 // StandardizeImage creates a square image by adding transparent padding to center the source image.
 // This ensures all icons have the same aspect ratio for consistent scaling and positioning.
-func StandardizeImage(src image.Image, size int) image.Image {
+// The square is then filled and masked according to style, so every icon source gets the same shape.
+func StandardizeImage(src image.Image, size int, style IconStyle) image.Image {
 	if size <= 0 {
 		size = 256 // Default standard size
 	}
@@ -64,13 +65,12 @@ func StandardizeImage(src image.Image, size int) image.Image {
 	// Create a square destination with transparent background
 	dst := image.NewRGBA(image.Rect(0, 0, size, size))
 
-	// Fill with transparent background
-	transparent := color.RGBA{0, 0, 0, 0}
-	for y := 0; y < size; y++ {
-		for x := 0; x < size; x++ {
-			dst.Set(x, y, transparent)
-		}
+	// Fill with transparent background, or the style's fill color
+	var fill color.Color = color.RGBA{0, 0, 0, 0}
+	if style.Background != nil {
+		fill = style.Background
 	}
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(fill), image.Point{}, draw.Src)
 
 	// Calculate position to center the source image
 	// Scale to fit within the square while preserving aspect ratio
@@ -97,9 +97,45 @@ func StandardizeImage(src image.Image, size int) image.Image {
 	offsetX := (size - scaledW) / 2
 	offsetY := (size - scaledH) / 2
 
-	// Scale and draw the source image centered
-	scaled := ScaleImage(src, scaledW, scaledH)
+	// Scale and draw the source image centered; vector icons are rasterized at the final size
+	var scaled image.Image
+	if svg, ok := src.(*SVGIcon); ok {
+		scaled = svg.Rasterize(scaledW, scaledH)
+	} else {
+		scaled = ScaleImage(src, scaledW, scaledH)
+	}
 	draw.Draw(dst, image.Rect(offsetX, offsetY, offsetX+scaledW, offsetY+scaledH), scaled, image.Point{}, draw.Over)
 
+	shaped := ApplyShapeMask(dst, style.Shape)
+	if style.Matte != nil {
+		return FlattenAlpha(shaped, style.Matte)
+	}
+	return shaped
+}
+
+// FlattenAlpha blends partially transparent pixels over matte and makes them opaque.
+// Fully transparent pixels stay transparent, so the terminal background still shows
+// through them, while anti-aliased edges get the color they would have on that background
+// instead of a halo when the encoder thresholds alpha.
+func FlattenAlpha(src image.Image, matte color.Color) image.Image {
+	bounds := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+	flattenNRGBA(dst, matte)
 	return dst
 }
+
+// flattenNRGBA is FlattenAlpha in place.
+func flattenNRGBA(img *image.NRGBA, matte color.Color) {
+	m := color.NRGBAModel.Convert(matte).(color.NRGBA)
+	for i := 0; i < len(img.Pix); i += 4 {
+		a := int(img.Pix[i+3])
+		if a == 0 || a == 255 {
+			continue
+		}
+		img.Pix[i] = uint8((int(img.Pix[i])*a + int(m.R)*(255-a)) / 255)
+		img.Pix[i+1] = uint8((int(img.Pix[i+1])*a + int(m.G)*(255-a)) / 255)
+		img.Pix[i+2] = uint8((int(img.Pix[i+2])*a + int(m.B)*(255-a)) / 255)
+		img.Pix[i+3] = 255
+	}
+}
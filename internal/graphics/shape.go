@@ -0,0 +1,167 @@
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// Icon mask shapes accepted by style.icon_shape.
+const (
+	ShapeNone          = "none"
+	ShapeCircle        = "circle"
+	ShapeSquircle      = "squircle"
+	ShapeRoundedSquare = "rounded-square"
+	ShapeSquare        = "square"
+	ShapeTeardrop      = "teardrop"
+)
+
+// IconStyle controls the shape applied to an icon while it is standardized.
+type IconStyle struct {
+	Shape      string      // Mask shape; "" or ShapeNone leaves the icon unmasked
+	Background color.Color // Fill drawn inside the shape behind the icon, nil for none
+	Backdrop   image.Image // Wallpaper pixels the icon is composited over, nil for none
+	Matte      color.Color // Terminal background that soft edges are flattened against, nil if unknown
+}
+
+// String returns a stable description of the style, used in cache keys.
+func (s IconStyle) String() string {
+	bg := "none"
+	if s.Background != nil {
+		bg = hexNRGBA(s.Background)
+	}
+	if s.Matte != nil {
+		bg += "/matte-" + hexNRGBA(s.Matte)
+	}
+	if s.Backdrop != nil {
+		bg += "/" + HashImage(s.Backdrop)[:16]
+	}
+	return s.Shape + "/" + bg
+}
+
+// hexNRGBA formats a color as rrggbbaa.
+func hexNRGBA(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("%02x%02x%02x%02x", n.R, n.G, n.B, n.A)
+}
+
+// ParseHexColor parses "#rrggbb" or "#rrggbbaa" into a color.
+func ParseHexColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	var c color.NRGBA
+	switch len(hex) {
+	case 6:
+		c.A = 255
+		if _, err := fmt.Sscanf(hex, "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+			return c, fmt.Errorf("invalid color %q", s)
+		}
+	case 8:
+		if _, err := fmt.Sscanf(hex, "%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A); err != nil {
+			return c, fmt.Errorf("invalid color %q", s)
+		}
+	default:
+		return c, fmt.Errorf("invalid color %q", s)
+	}
+	return c, nil
+}
+
+// shapeSupersample is the per-axis subpixel count used to anti-alias mask edges.
+const shapeSupersample = 4
+
+// ShapeMask returns an anti-aliased alpha mask of the given shape and size.
+// Returns nil for ShapeNone or unknown shapes.
+func ShapeMask(shape string, size int) *image.Alpha {
+	if size <= 0 || !isMaskShape(shape) {
+		return nil
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, size, size))
+	samples := shapeSupersample * shapeSupersample
+	half := float64(size) / 2
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			inside := 0
+			for sy := 0; sy < shapeSupersample; sy++ {
+				for sx := 0; sx < shapeSupersample; sx++ {
+					// Normalize subpixel center to -1..1
+					nx := (float64(x)+(float64(sx)+0.5)/shapeSupersample)/half - 1
+					ny := (float64(y)+(float64(sy)+0.5)/shapeSupersample)/half - 1
+					if insideShape(shape, nx, ny) {
+						inside++
+					}
+				}
+			}
+			mask.SetAlpha(x, y, color.Alpha{A: uint8(inside * 255 / samples)})
+		}
+	}
+	return mask
+}
+
+// isMaskShape reports whether shape produces a mask.
+func isMaskShape(shape string) bool {
+	switch shape {
+	case ShapeCircle, ShapeSquircle, ShapeRoundedSquare, ShapeSquare, ShapeTeardrop:
+		return true
+	}
+	return false
+}
+
+// insideShape reports whether the normalized point (x, y in -1..1) lies inside the shape.
+func insideShape(shape string, x, y float64) bool {
+	switch shape {
+	case ShapeCircle:
+		return x*x+y*y <= 1
+	case ShapeSquircle:
+		return math.Pow(math.Abs(x), 4)+math.Pow(math.Abs(y), 4) <= 1
+	case ShapeRoundedSquare:
+		return insideRoundedRect(x, y, 0.4)
+	case ShapeSquare:
+		return math.Abs(x) <= 1 && math.Abs(y) <= 1
+	case ShapeTeardrop:
+		// Circle with a square top-right corner, like Android's teardrop shape
+		if x >= 0 && y <= 0 {
+			return true
+		}
+		return x*x+y*y <= 1
+	}
+	return true
+}
+
+// insideRoundedRect tests a point against the -1..1 square with corner radius r.
+func insideRoundedRect(x, y, r float64) bool {
+	ax, ay := math.Abs(x), math.Abs(y)
+	if ax > 1 || ay > 1 {
+		return false
+	}
+	cx, cy := ax-(1-r), ay-(1-r)
+	if cx <= 0 || cy <= 0 {
+		return true
+	}
+	return cx*cx+cy*cy <= r*r
+}
+
+// ApplyShapeMask returns a copy of a square image with its alpha multiplied by the shape mask.
+func ApplyShapeMask(src image.Image, shape string) image.Image {
+	bounds := src.Bounds()
+	size := bounds.Dx()
+	if bounds.Dy() > size {
+		size = bounds.Dy()
+	}
+	mask := ShapeMask(shape, size)
+	if mask == nil {
+		return src
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			c := color.NRGBAModel.Convert(src.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			c.A = uint8(int(c.A) * int(mask.AlphaAt(x, y).A) / 255)
+			dst.SetNRGBA(x, y, c)
+		}
+	}
+	return dst
+}
@@ -2,6 +2,7 @@ package graphics
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
@@ -12,70 +13,87 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-
-	"github.com/mattn/go-sixel"
+	"strings"
 
 	"tooie-shelf/internal/sys"
 )
 
-// SixelResult contains the sixel string and its pixel dimensions.
-type SixelResult struct {
-	Sixel  string
-	Width  int // Actual pixel width of rendered sixel
-	Height int // Actual pixel height of rendered sixel
+// SixelRenderer renders images using the DEC Sixel protocol with the built-in encoder.
+type SixelRenderer struct {
+	Options SixelOptions
+}
+
+// Name returns the protocol name.
+func (SixelRenderer) Name() string {
+	return ProtocolSixel
+}
+
+// Render converts an image to a sixel payload.
+func (r SixelRenderer) Render(src image.Image, widthCells, heightCells int, cellPx sys.CellDim, style IconStyle) Payload {
+	return RenderSixelWithOptions(src, widthCells, heightCells, cellPx, r.Options, style)
+}
+
+// Delete is a no-op: sixels are plain cell content and get overwritten by redraws.
+func (SixelRenderer) Delete(p Payload) string {
+	return ""
+}
+
+// ClearAll is a no-op for sixel.
+func (SixelRenderer) ClearAll() string {
+	return ""
 }
 
 // RenderSixel converts an image to a sixel string sized for the given cell dimensions.
 func RenderSixel(src image.Image, widthCells, heightCells int, cellPx sys.CellDim) string {
 	result := RenderSixelWithDimensions(src, widthCells, heightCells, cellPx)
-	return result.Sixel
+	return result.Data
 }
 
 // RenderSixelWithDimensions converts an image to a sixel string and returns the actual pixel dimensions.
 // All icons are standardized to a square format before scaling to ensure consistent sizing.
-func RenderSixelWithDimensions(src image.Image, widthCells, heightCells int, cellPx sys.CellDim) SixelResult {
-	targetW := widthCells * cellPx.Width
-	targetH := heightCells * cellPx.Height
-
-	if targetW <= 0 || targetH <= 0 {
-		return SixelResult{}
-	}
+func RenderSixelWithDimensions(src image.Image, widthCells, heightCells int, cellPx sys.CellDim) Payload {
+	return RenderSixelWithOptions(src, widthCells, heightCells, cellPx, SixelOptions{}, IconStyle{})
+}
 
-	// Standardize to square format first to ensure all icons have same aspect ratio
-	// Use the larger dimension as the standard size
-	stdSize := targetW
-	if targetH > targetW {
-		stdSize = targetH
+// RenderSixelWithOptions is RenderSixelWithDimensions with explicit palette, dithering and shape options.
+func RenderSixelWithOptions(src image.Image, widthCells, heightCells int, cellPx sys.CellDim, opts SixelOptions, style IconStyle) Payload {
+	scaled := prepareIcon(src, widthCells, heightCells, cellPx, style)
+	if scaled == nil {
+		return Payload{}
 	}
-
-	// Create standardized square icon
-	standardized := StandardizeImage(src, stdSize)
-
-	// Now scale to fit exactly within target dimensions
-	scaled := ScaleImageAspectFit(standardized, targetW, targetH)
 	bounds := scaled.Bounds()
 
-	var buf bytes.Buffer
-	enc := sixel.NewEncoder(&buf)
-	if err := enc.Encode(scaled); err != nil {
-		return SixelResult{}
+	data := EncodeSixel(scaled, opts)
+	if data == "" {
+		return Payload{}
 	}
-	return SixelResult{
-		Sixel:  buf.String(),
+	return Payload{
+		Data:   data,
 		Width:  bounds.Dx(),
 		Height: bounds.Dy(),
 	}
 }
 
 // LoadImage loads an image from a file path.
+// SVG files are parsed as vectors and rasterized at the exact size they are rendered at.
 func LoadImage(path string) (image.Image, error) {
-	f, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	return decodeIcon(data)
+}
 
-	img, _, err := image.Decode(f)
+// decodeIcon decodes raster image data or an SVG document.
+// Animated GIFs and WebPs decode to an *AnimatedIcon.
+func decodeIcon(data []byte) (image.Image, error) {
+	if IsSVG(data) {
+		return ParseSVG(data)
+	}
+	if anim, err := decodeAnimation(data); anim != nil || err != nil {
+		return anim, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
@@ -106,43 +124,71 @@ func SaveImage(img image.Image, path string) error {
 
 // FetchDashboardIcon downloads an icon from the Dashboard Icons CDN.
 // Format: "https://cdn.jsdelivr.net/gh/homarr-labs/dashboard-icons/png/{name}.png"
-func FetchDashboardIcon(iconName string) (image.Image, error) {
-	if iconName == "" {
+// Names prefixed with "svg:" fetch the SVG variant from ".../svg/{name}.svg".
+func FetchDashboardIcon(ctx context.Context, iconName string) (image.Image, error) {
+	url := DashboardIconURL(iconName)
+	if url == "" {
 		return nil, fmt.Errorf("empty icon name")
 	}
+	return FetchIconFromURL(ctx, url)
+}
 
-	url := fmt.Sprintf("https://cdn.jsdelivr.net/gh/homarr-labs/dashboard-icons/png/%s.png", iconName)
-	return FetchIconFromURL(url)
+// DashboardIconURL returns the CDN URL of a Dashboard Icons name, or "" for an empty name.
+func DashboardIconURL(iconName string) string {
+	format := "png"
+	if name, ok := strings.CutPrefix(iconName, "svg:"); ok {
+		format, iconName = "svg", name
+	}
+	if iconName == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://cdn.jsdelivr.net/gh/homarr-labs/dashboard-icons/%s/%s.%s", format, iconName, format)
 }
 
 // FetchIconFromURL downloads an icon from a URL with local caching.
 // Cached icons are stored in ~/.config/tooie-shelf/icons/ with a hash of the URL as filename.
-func FetchIconFromURL(url string) (image.Image, error) {
+// The download is killed once ctx is done.
+func FetchIconFromURL(ctx context.Context, url string) (image.Image, error) {
 	if url == "" {
 		return nil, fmt.Errorf("empty URL")
 	}
 
-	// Check local cache first
+	// Check local cache first; SVGs and animations are cached as the original file
 	cachePath := getURLIconCachePath(url)
+	svgCachePath := strings.TrimSuffix(cachePath, ".png") + ".svg"
+	animCachePath := strings.TrimSuffix(cachePath, ".png") + ".anim"
+	if cached, err := LoadImage(svgCachePath); err == nil {
+		return cached, nil
+	}
+	if cached, err := LoadImage(animCachePath); err == nil {
+		return cached, nil
+	}
 	if cached, err := LoadImage(cachePath); err == nil {
 		return cached, nil
 	}
 
 	// Download from URL
-	cmd := exec.Command("curl", "-sL", "--max-time", "10", url)
+	cmd := exec.CommandContext(ctx, "curl", "-sL", url)
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch icon from %s: %w", url, err)
 	}
 
-	img, _, err := image.Decode(bytes.NewReader(output))
+	img, err := decodeIcon(output)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode icon: %w", err)
 	}
 
 	// Save to cache
 	_ = os.MkdirAll(filepath.Dir(cachePath), 0755)
-	_ = SaveImage(img, cachePath)
+	switch img.(type) {
+	case *SVGIcon:
+		_ = os.WriteFile(svgCachePath, output, 0644)
+	case *AnimatedIcon:
+		_ = os.WriteFile(animCachePath, output, 0644)
+	default:
+		_ = SaveImage(img, cachePath)
+	}
 
 	return img, nil
 }
@@ -0,0 +1,302 @@
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+	"strings"
+)
+
+// Quantizer names accepted by style.sixel.quantizer.
+const (
+	QuantizerMedianCut = "median-cut"
+	QuantizerOctree    = "octree"
+)
+
+// Dither names accepted by style.sixel.dither.
+const (
+	DitherFloydSteinberg = "floyd-steinberg"
+	DitherOrdered        = "ordered"
+	DitherNone           = "none"
+)
+
+// Palette size limits for the sixel encoder.
+const (
+	minSixelColors     = 2
+	maxSixelColors     = 256
+	defaultSixelColors = 256
+)
+
+// SixelOptions controls palette generation and dithering for the sixel encoder.
+type SixelOptions struct {
+	Colors    int    // Palette size (2-256), 0 for default
+	Quantizer string // "median-cut" (default) or "octree"
+	Dither    string // "floyd-steinberg" (default), "ordered" or "none"
+
+	// Background is the terminal background color (OSC 11). When known, soft edges
+	// are blended against it instead of being cut at the alpha threshold.
+	Background color.Color
+}
+
+// normalized returns options with defaults filled in and the color count clamped.
+func (o SixelOptions) normalized() SixelOptions {
+	if o.Colors <= 0 {
+		o.Colors = defaultSixelColors
+	}
+	if o.Colors < minSixelColors {
+		o.Colors = minSixelColors
+	}
+	if o.Colors > maxSixelColors {
+		o.Colors = maxSixelColors
+	}
+	if o.Quantizer == "" {
+		o.Quantizer = QuantizerMedianCut
+	}
+	if o.Dither == "" {
+		o.Dither = DitherFloydSteinberg
+	}
+	return o
+}
+
+// EncodeSixel encodes an image as a sixel string with a transparent background.
+// Pixels with alpha below the threshold are left unpainted (P2=1) so the
+// terminal background shows through. With a known background color, partially
+// transparent pixels are blended against it first.
+func EncodeSixel(src image.Image, opts SixelOptions) string {
+	opts = opts.normalized()
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), src, bounds.Min, draw.Src)
+	if opts.Background != nil {
+		flattenNRGBA(img, opts.Background)
+	}
+
+	// Build palette from visible pixels only
+	var visible []color.NRGBA
+	for i := 0; i < len(img.Pix); i += 4 {
+		if img.Pix[i+3] >= alphaThreshold {
+			visible = append(visible, color.NRGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], 255})
+		}
+	}
+	if len(visible) == 0 {
+		return ""
+	}
+
+	var palette []color.NRGBA
+	switch opts.Quantizer {
+	case QuantizerOctree:
+		palette = quantizeOctree(visible, opts.Colors)
+	default:
+		palette = quantizeMedianCut(visible, opts.Colors)
+	}
+
+	indices := mapToPalette(img, palette, opts.Dither)
+	return writeSixel(indices, palette, width, height)
+}
+
+// mapToPalette assigns a palette index to every pixel, -1 for transparent pixels.
+func mapToPalette(img *image.NRGBA, palette []color.NRGBA, dither string) []int {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	indices := make([]int, width*height)
+	lookup := newPaletteLookup(palette)
+
+	switch dither {
+	case DitherFloydSteinberg:
+		// Error buffers for the current and next row (RGB per pixel)
+		cur := make([][3]float64, width+2)
+		next := make([][3]float64, width+2)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				off := img.PixOffset(x, y)
+				if img.Pix[off+3] < alphaThreshold {
+					indices[y*width+x] = -1
+					continue
+				}
+				want := [3]float64{
+					float64(img.Pix[off]) + cur[x+1][0],
+					float64(img.Pix[off+1]) + cur[x+1][1],
+					float64(img.Pix[off+2]) + cur[x+1][2],
+				}
+				idx := lookup.nearest(clamp8(want[0]), clamp8(want[1]), clamp8(want[2]))
+				indices[y*width+x] = idx
+
+				got := palette[idx]
+				errs := [3]float64{want[0] - float64(got.R), want[1] - float64(got.G), want[2] - float64(got.B)}
+				for c := 0; c < 3; c++ {
+					cur[x+2][c] += errs[c] * 7 / 16
+					next[x][c] += errs[c] * 3 / 16
+					next[x+1][c] += errs[c] * 5 / 16
+					next[x+2][c] += errs[c] * 1 / 16
+				}
+			}
+			cur, next = next, cur
+			for i := range next {
+				next[i] = [3]float64{}
+			}
+		}
+
+	case DitherOrdered:
+		// Spread the threshold roughly by the palette's average step size
+		spread := 255.0 / float64(len(palette))
+		if spread < 8 {
+			spread = 8
+		}
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				off := img.PixOffset(x, y)
+				if img.Pix[off+3] < alphaThreshold {
+					indices[y*width+x] = -1
+					continue
+				}
+				t := (bayer4x4[y%4][x%4]/16.0 - 0.5) * spread
+				indices[y*width+x] = lookup.nearest(
+					clamp8(float64(img.Pix[off])+t),
+					clamp8(float64(img.Pix[off+1])+t),
+					clamp8(float64(img.Pix[off+2])+t),
+				)
+			}
+		}
+
+	default:
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				off := img.PixOffset(x, y)
+				if img.Pix[off+3] < alphaThreshold {
+					indices[y*width+x] = -1
+					continue
+				}
+				indices[y*width+x] = lookup.nearest(img.Pix[off], img.Pix[off+1], img.Pix[off+2])
+			}
+		}
+	}
+
+	return indices
+}
+
+// bayer4x4 is the 4x4 ordered dithering threshold matrix.
+var bayer4x4 = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// paletteLookup finds nearest palette entries, memoizing results per color.
+type paletteLookup struct {
+	palette []color.NRGBA
+	cache   map[uint32]int
+}
+
+func newPaletteLookup(palette []color.NRGBA) *paletteLookup {
+	return &paletteLookup{palette: palette, cache: make(map[uint32]int)}
+}
+
+// nearest returns the index of the palette color closest to r, g, b.
+func (l *paletteLookup) nearest(r, g, b uint8) int {
+	key := uint32(r)<<16 | uint32(g)<<8 | uint32(b)
+	if idx, ok := l.cache[key]; ok {
+		return idx
+	}
+
+	best, bestDist := 0, -1
+	for i, p := range l.palette {
+		d := sqDist(int(r), int(g), int(b), int(p.R), int(p.G), int(p.B))
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	l.cache[key] = best
+	return best
+}
+
+// writeSixel serializes palette indices as a DEC sixel sequence.
+func writeSixel(indices []int, palette []color.NRGBA, width, height int) string {
+	var b strings.Builder
+
+	// P1=0 (default aspect), P2=1 (unpainted pixels stay transparent), P3=0
+	b.WriteString("\x1bP0;1;0q")
+	fmt.Fprintf(&b, "\"1;1;%d;%d", width, height)
+
+	// Color registers use RGB percentages
+	for i, c := range palette {
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, int(c.R)*100/255, int(c.G)*100/255, int(c.B)*100/255)
+	}
+
+	row := make([]byte, width)
+	for band := 0; band < height; band += 6 {
+		// Find colors used in this band, in palette order
+		used := make(map[int]bool)
+		for y := band; y < band+6 && y < height; y++ {
+			for x := 0; x < width; x++ {
+				if idx := indices[y*width+x]; idx >= 0 {
+					used[idx] = true
+				}
+			}
+		}
+		colors := make([]int, 0, len(used))
+		for idx := range used {
+			colors = append(colors, idx)
+		}
+		sort.Ints(colors)
+
+		for n, idx := range colors {
+			for x := 0; x < width; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && band+dy < height; dy++ {
+					if indices[(band+dy)*width+x] == idx {
+						bits |= 1 << dy
+					}
+				}
+				row[x] = '?' + bits
+			}
+
+			fmt.Fprintf(&b, "#%d", idx)
+			writeSixelRLE(&b, row)
+			if n < len(colors)-1 {
+				b.WriteByte('$') // Carriage return: overlay next color on the same band
+			}
+		}
+		b.WriteByte('-') // Next band
+	}
+
+	b.WriteString("\x1b\\")
+	return b.String()
+}
+
+// writeSixelRLE writes a row of sixel characters with run-length compression.
+func writeSixelRLE(b *strings.Builder, row []byte) {
+	for i := 0; i < len(row); {
+		j := i + 1
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		run := j - i
+		if run > 3 {
+			fmt.Fprintf(b, "!%d%c", run, row[i])
+		} else {
+			for k := 0; k < run; k++ {
+				b.WriteByte(row[i])
+			}
+		}
+		i = j
+	}
+}
+
+// clamp8 clamps a float to the 0-255 byte range.
+func clamp8(v float64) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
@@ -0,0 +1,878 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// svgPreviewSize is the longest side of the raster an SVGIcon shows through the
+// image.Image interface. Rendering rasterizes at the target size instead, themed there too.
+const svgPreviewSize = 256
+
+// svgMaxUseDepth bounds nested <use> references.
+const svgMaxUseDepth = 8
+
+// SVGIcon is a parsed SVG document. It implements image.Image with a preview
+// raster, and Rasterize renders it at any exact pixel size.
+type SVGIcon struct {
+	root    *svgNode
+	ids     map[string]*svgNode
+	rules   []svgRule
+	viewBox [4]float64 // minX, minY, width, height
+	stretch bool       // preserveAspectRatio="none"
+	preview *image.NRGBA
+	recolor func(*image.NRGBA) *image.NRGBA // Applied to every raster, see Recolor
+}
+
+// svgNode is a parsed SVG element.
+type svgNode struct {
+	name     string
+	attrs    map[string]string
+	children []*svgNode
+	text     string
+}
+
+// svgRule is one CSS declaration block from a <style> element.
+type svgRule struct {
+	selector    string
+	specificity int
+	decls       map[string]string
+}
+
+// IsSVG reports whether data looks like an SVG document.
+func IsSVG(data []byte) bool {
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	return bytes.Contains(head, []byte("<svg"))
+}
+
+// ParseSVG parses an SVG document.
+func ParseSVG(data []byte) (*SVGIcon, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+
+	s := &SVGIcon{ids: make(map[string]*svgNode)}
+	var stack []*svgNode
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &svgNode{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				n.attrs[a.Name.Local] = a.Value
+			}
+			if id := n.attrs["id"]; id != "" {
+				s.ids[id] = n
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else if s.root == nil {
+				s.root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if s.root == nil || s.root.name != "svg" {
+		return nil, fmt.Errorf("not an SVG document")
+	}
+
+	s.collectStyles(s.root)
+	s.viewBox = svgViewBox(s.root)
+	if s.viewBox[2] <= 0 || s.viewBox[3] <= 0 {
+		return nil, fmt.Errorf("SVG has no usable size")
+	}
+	s.stretch = strings.TrimSpace(s.root.attrs["preserveAspectRatio"]) == "none"
+
+	w, h := svgPreviewSize, svgPreviewSize
+	if s.viewBox[2] > s.viewBox[3] {
+		h = int(math.Round(svgPreviewSize * s.viewBox[3] / s.viewBox[2]))
+	} else {
+		w = int(math.Round(svgPreviewSize * s.viewBox[2] / s.viewBox[3]))
+	}
+	s.preview = s.Rasterize(max(w, 1), max(h, 1))
+	return s, nil
+}
+
+// svgViewBox returns the root viewBox, falling back to width/height.
+func svgViewBox(root *svgNode) [4]float64 {
+	if v := parseNumberList(root.attrs["viewBox"]); len(v) == 4 {
+		return [4]float64{v[0], v[1], v[2], v[3]}
+	}
+	w := parseLength(root.attrs["width"], 0)
+	h := parseLength(root.attrs["height"], 0)
+	if w <= 0 || h <= 0 {
+		w, h = 100, 100
+	}
+	return [4]float64{0, 0, w, h}
+}
+
+// ColorModel implements image.Image.
+func (s *SVGIcon) ColorModel() color.Model { return color.NRGBAModel }
+
+// Bounds implements image.Image using the preview raster.
+func (s *SVGIcon) Bounds() image.Rectangle { return s.preview.Bounds() }
+
+// At implements image.Image using the preview raster.
+func (s *SVGIcon) At(x, y int) color.Color { return s.preview.At(x, y) }
+
+// Rasterize renders the SVG at exactly width x height pixels.
+func (s *SVGIcon) Rasterize(width, height int) *image.NRGBA {
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	// Map the viewBox onto the canvas (xMidYMid meet unless stretched)
+	sx := float64(width) / s.viewBox[2]
+	sy := float64(height) / s.viewBox[3]
+	tx, ty := 0.0, 0.0
+	if !s.stretch {
+		scale := math.Min(sx, sy)
+		tx = (float64(width) - s.viewBox[2]*scale) / 2
+		ty = (float64(height) - s.viewBox[3]*scale) / 2
+		sx, sy = scale, scale
+	}
+	m := svgMatrix{sx, 0, 0, sy, tx - s.viewBox[0]*sx, ty - s.viewBox[1]*sy}
+
+	r := svgRenderer{doc: s, canvas: canvas}
+	r.renderChildren(s.root, m, r.computeStyle(s.root, defaultSVGStyle()), 0)
+
+	// Convert premultiplied RGBA to NRGBA
+	dst := image.NewNRGBA(canvas.Bounds())
+	for i := 0; i < len(canvas.Pix); i += 4 {
+		a := canvas.Pix[i+3]
+		if a == 0 {
+			continue
+		}
+		for c := 0; c < 3; c++ {
+			dst.Pix[i+c] = uint8(min(255, int(canvas.Pix[i+c])*255/int(a)))
+		}
+		dst.Pix[i+3] = a
+	}
+	if s.recolor != nil {
+		return s.recolor(dst)
+	}
+	return dst
+}
+
+// Recolor returns a copy of the SVG whose rasters pass through f at every size, so
+// recoloring happens at the final resolution rather than on the preview.
+func (s *SVGIcon) Recolor(f func(*image.NRGBA) *image.NRGBA) *SVGIcon {
+	c := *s
+	c.recolor = f
+	if s.recolor != nil {
+		c.recolor = func(img *image.NRGBA) *image.NRGBA { return f(s.recolor(img)) }
+	}
+	c.preview = f(s.preview)
+	return &c
+}
+
+// svgStyle holds the presentation properties of an element.
+type svgStyle struct {
+	fill, stroke                        string
+	fillOpacity, strokeOpacity, opacity float64
+	evenOdd                             bool
+	strokeWidth, miterLimit             float64
+	lineCap, lineJoin                   string
+	color                               string
+	hidden                              bool
+}
+
+func defaultSVGStyle() svgStyle {
+	return svgStyle{
+		fill:          "black",
+		stroke:        "none",
+		fillOpacity:   1,
+		strokeOpacity: 1,
+		opacity:       1,
+		strokeWidth:   1,
+		miterLimit:    4,
+		lineCap:       "butt",
+		lineJoin:      "miter",
+		color:         "black",
+	}
+}
+
+// svgRenderer draws a document onto a premultiplied canvas.
+type svgRenderer struct {
+	doc    *SVGIcon
+	canvas *image.RGBA
+}
+
+// collectStyles parses every <style> element into CSS rules.
+func (s *SVGIcon) collectStyles(n *svgNode) {
+	if n.name == "style" {
+		s.rules = append(s.rules, parseCSS(n.text)...)
+	}
+	for _, c := range n.children {
+		s.collectStyles(c)
+	}
+}
+
+// parseCSS parses simple CSS rule sets (tag, .class, #id and tag.class selectors).
+func parseCSS(css string) []svgRule {
+	// Strip comments
+	for {
+		start := strings.Index(css, "/*")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(css[start+2:], "*/")
+		if end < 0 {
+			css = css[:start]
+			break
+		}
+		css = css[:start] + css[start+2+end+2:]
+	}
+
+	var rules []svgRule
+	for _, block := range strings.Split(css, "}") {
+		open := strings.IndexByte(block, '{')
+		if open < 0 {
+			continue
+		}
+		decls := parseDeclarations(block[open+1:])
+		for _, sel := range strings.Split(block[:open], ",") {
+			sel = strings.TrimSpace(sel)
+			if sel == "" || strings.ContainsAny(sel, " >+~:[") {
+				continue // Only simple selectors are supported
+			}
+			spec := 1
+			switch {
+			case strings.HasPrefix(sel, "#"):
+				spec = 100
+			case strings.Contains(sel, "."):
+				spec = 10
+			}
+			rules = append(rules, svgRule{selector: sel, specificity: spec, decls: decls})
+		}
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].specificity < rules[j].specificity })
+	return rules
+}
+
+// parseDeclarations parses "prop: value; prop: value".
+func parseDeclarations(s string) map[string]string {
+	decls := make(map[string]string)
+	for _, d := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(d, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		decls[strings.TrimSpace(name)] = value
+	}
+	return decls
+}
+
+// matches reports whether a simple selector matches a node.
+func (r svgRule) matches(n *svgNode) bool {
+	sel := r.selector
+	if strings.HasPrefix(sel, "#") {
+		return n.attrs["id"] == sel[1:]
+	}
+	tag, class, hasClass := strings.Cut(sel, ".")
+	if tag != "" && tag != "*" && tag != n.name {
+		return false
+	}
+	if !hasClass {
+		return true
+	}
+	for _, c := range strings.Fields(n.attrs["class"]) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// properties returns an element's declared properties: presentation attributes,
+// overridden by CSS rules, overridden by the style attribute.
+func (r *svgRenderer) properties(n *svgNode) map[string]string {
+	props := make(map[string]string)
+	for k, v := range n.attrs {
+		props[k] = v
+	}
+	for _, rule := range r.doc.rules {
+		if rule.matches(n) {
+			for k, v := range rule.decls {
+				props[k] = v
+			}
+		}
+	}
+	for k, v := range parseDeclarations(n.attrs["style"]) {
+		props[k] = v
+	}
+	return props
+}
+
+// computeStyle resolves an element's style from its properties and its parent's style.
+func (r *svgRenderer) computeStyle(n *svgNode, parent svgStyle) svgStyle {
+	st := parent
+	st.opacity = 1 // Not inherited; applied multiplicatively below
+	props := r.properties(n)
+
+	num := func(name string, dst *float64) {
+		if v, ok := props[name]; ok && v != "inherit" {
+			if f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(v), "%"), 64); err == nil {
+				if strings.HasSuffix(strings.TrimSpace(v), "%") {
+					f /= 100
+				}
+				*dst = f
+			}
+		}
+	}
+	str := func(name string, dst *string) {
+		if v, ok := props[name]; ok && v != "inherit" && v != "" {
+			*dst = strings.TrimSpace(v)
+		}
+	}
+
+	str("fill", &st.fill)
+	str("stroke", &st.stroke)
+	str("color", &st.color)
+	str("stroke-linecap", &st.lineCap)
+	str("stroke-linejoin", &st.lineJoin)
+	num("fill-opacity", &st.fillOpacity)
+	num("stroke-opacity", &st.strokeOpacity)
+	num("opacity", &st.opacity)
+	num("stroke-miterlimit", &st.miterLimit)
+	if v, ok := props["stroke-width"]; ok {
+		st.strokeWidth = parseLength(v, r.doc.viewBox[2])
+	}
+	if v, ok := props["fill-rule"]; ok {
+		st.evenOdd = strings.TrimSpace(v) == "evenodd"
+	}
+	if props["display"] == "none" || props["visibility"] == "hidden" {
+		st.hidden = true
+	}
+	st.opacity *= parent.opacity
+	return st
+}
+
+// renderChildren renders the children of a container element.
+func (r *svgRenderer) renderChildren(n *svgNode, m svgMatrix, st svgStyle, depth int) {
+	for _, c := range n.children {
+		r.renderNode(c, m, st, depth)
+	}
+}
+
+// renderNode renders one element and its subtree.
+func (r *svgRenderer) renderNode(n *svgNode, m svgMatrix, parent svgStyle, depth int) {
+	switch n.name {
+	case "defs", "style", "title", "desc", "metadata", "clipPath", "mask", "symbol",
+		"linearGradient", "radialGradient", "pattern", "filter", "marker", "text":
+		return
+	}
+
+	st := r.computeStyle(n, parent)
+	if st.hidden {
+		return
+	}
+	if t, ok := n.attrs["transform"]; ok {
+		m = m.mul(parseTransform(t))
+	}
+
+	vw, vh := r.doc.viewBox[2], r.doc.viewBox[3]
+	attr := func(name string, ref float64) float64 { return parseLength(n.attrs[name], ref) }
+
+	b := newPathBuilder(m)
+	switch n.name {
+	case "g", "a", "switch", "svg":
+		r.renderChildren(n, m, st, depth)
+		return
+
+	case "use":
+		href := n.attrs["href"]
+		target := r.doc.ids[strings.TrimPrefix(href, "#")]
+		if target == nil || depth >= svgMaxUseDepth {
+			return
+		}
+		m = m.mul(svgMatrix{1, 0, 0, 1, attr("x", vw), attr("y", vh)})
+		if target.name == "symbol" {
+			r.renderChildren(target, m, r.computeStyle(target, st), depth+1)
+			return
+		}
+		r.renderNode(target, m, st, depth+1)
+		return
+
+	case "path":
+		if err := buildPathData(b, n.attrs["d"]); err != nil && len(b.lines) == 0 {
+			return
+		}
+
+	case "rect":
+		x, y, w, h := attr("x", vw), attr("y", vh), attr("width", vw), attr("height", vh)
+		if w <= 0 || h <= 0 {
+			return
+		}
+		rx, hasRx := n.attrs["rx"]
+		ry, hasRy := n.attrs["ry"]
+		rxv, ryv := parseLength(rx, vw), parseLength(ry, vh)
+		if !hasRx {
+			rxv = ryv
+		}
+		if !hasRy {
+			ryv = rxv
+		}
+		rxv, ryv = math.Min(rxv, w/2), math.Min(ryv, h/2)
+		if rxv <= 0 || ryv <= 0 {
+			b.moveTo(x, y)
+			b.lineTo(x+w, y)
+			b.lineTo(x+w, y+h)
+			b.lineTo(x, y+h)
+		} else {
+			b.moveTo(x+rxv, y)
+			b.lineTo(x+w-rxv, y)
+			b.arcTo(x+w-rxv, y, rxv, ryv, 0, false, true, x+w, y+ryv)
+			b.lineTo(x+w, y+h-ryv)
+			b.arcTo(x+w, y+h-ryv, rxv, ryv, 0, false, true, x+w-rxv, y+h)
+			b.lineTo(x+rxv, y+h)
+			b.arcTo(x+rxv, y+h, rxv, ryv, 0, false, true, x, y+h-ryv)
+			b.lineTo(x, y+ryv)
+			b.arcTo(x, y+ryv, rxv, ryv, 0, false, true, x+rxv, y)
+		}
+		b.close()
+
+	case "circle", "ellipse":
+		cx, cy := attr("cx", vw), attr("cy", vh)
+		rx, ry := attr("rx", vw), attr("ry", vh)
+		if n.name == "circle" {
+			rx = attr("r", math.Hypot(vw, vh)/math.Sqrt2)
+			ry = rx
+		}
+		if rx <= 0 || ry <= 0 {
+			return
+		}
+		b.moveTo(cx+rx, cy)
+		b.arcTo(cx+rx, cy, rx, ry, 0, false, true, cx-rx, cy)
+		b.arcTo(cx-rx, cy, rx, ry, 0, false, true, cx+rx, cy)
+		b.close()
+
+	case "line":
+		b.moveTo(attr("x1", vw), attr("y1", vh))
+		b.lineTo(attr("x2", vw), attr("y2", vh))
+
+	case "polyline", "polygon":
+		pts := parseNumberList(n.attrs["points"])
+		for i := 0; i+1 < len(pts); i += 2 {
+			if i == 0 {
+				b.moveTo(pts[i], pts[i+1])
+			} else {
+				b.lineTo(pts[i], pts[i+1])
+			}
+		}
+		if n.name == "polygon" {
+			b.close()
+		}
+
+	default:
+		r.renderChildren(n, m, st, depth)
+		return
+	}
+
+	if len(b.lines) == 0 {
+		return
+	}
+
+	// Fill, then stroke on top
+	if n.name != "line" {
+		if paint := r.resolvePaint(st.fill, st.color); paint != nil {
+			cov := rasterizePolygons(b.polygons(), r.canvas.Bounds().Dx(), r.canvas.Bounds().Dy(), st.evenOdd)
+			r.composite(cov, paint, m, b.bbox, st.opacity*st.fillOpacity)
+		}
+	}
+	if paint := r.resolvePaint(st.stroke, st.color); paint != nil && st.strokeWidth > 0 {
+		polys := strokePolylines(b.lines, st.strokeWidth*m.scale(), st.lineCap, st.lineJoin, st.miterLimit)
+		cov := rasterizePolygons(polys, r.canvas.Bounds().Dx(), r.canvas.Bounds().Dy(), false)
+		r.composite(cov, paint, m, b.bbox, st.opacity*st.strokeOpacity)
+	}
+}
+
+// composite blends a paint through a coverage mask onto the canvas.
+func (r *svgRenderer) composite(cov *svgCoverage, paint *svgPaint, m svgMatrix, bbox [4]float64, opacity float64) {
+	inv := m.invert()
+	for y := 0; y < cov.h; y++ {
+		for x := 0; x < cov.w; x++ {
+			a := cov.at(x, y)
+			if a <= 0 {
+				continue
+			}
+			c := paint.solid
+			if paint.gradient != nil {
+				u := inv.apply(float64(x)+0.5, float64(y)+0.5)
+				c = paint.gradient.colorAt(u.x, u.y, bbox)
+			}
+			alpha := a * opacity * float64(c.A) / 255
+			if alpha <= 0 {
+				continue
+			}
+
+			i := r.canvas.PixOffset(x, y)
+			pix := r.canvas.Pix[i : i+4 : i+4]
+			keep := 1 - alpha
+			pix[0] = uint8(float64(c.R)*alpha + float64(pix[0])*keep + 0.5)
+			pix[1] = uint8(float64(c.G)*alpha + float64(pix[1])*keep + 0.5)
+			pix[2] = uint8(float64(c.B)*alpha + float64(pix[2])*keep + 0.5)
+			pix[3] = uint8(255*alpha + float64(pix[3])*keep + 0.5)
+		}
+	}
+}
+
+// svgPaint is a resolved fill or stroke: a solid color or a gradient.
+type svgPaint struct {
+	solid    color.NRGBA
+	gradient *svgGradient
+}
+
+// resolvePaint resolves a paint specification, or nil for "none".
+func (r *svgRenderer) resolvePaint(spec, current string) *svgPaint {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "none" {
+		return nil
+	}
+	if strings.HasPrefix(spec, "url(") {
+		end := strings.IndexByte(spec, ')')
+		if end < 0 {
+			return nil
+		}
+		id := strings.Trim(strings.TrimSpace(spec[4:end]), "'\"")
+		if g := r.gradient(strings.TrimPrefix(id, "#"), 0); g != nil {
+			if len(g.stops) == 1 {
+				return &svgPaint{solid: g.stops[0].c}
+			}
+			return &svgPaint{gradient: g}
+		}
+		// Fallback color after the URL, if any
+		spec = strings.TrimSpace(spec[end+1:])
+		if spec == "" {
+			return nil
+		}
+	}
+	if spec == "currentColor" {
+		spec = current
+	}
+	c, ok := parseSVGColor(spec)
+	if !ok {
+		return nil
+	}
+	return &svgPaint{solid: c}
+}
+
+// svgStop is a gradient color stop.
+type svgStop struct {
+	offset float64
+	c      color.NRGBA
+}
+
+// svgGradient is a linear or radial gradient with pad spread.
+type svgGradient struct {
+	radial         bool
+	userSpace      bool // gradientUnits="userSpaceOnUse"
+	transform      svgMatrix
+	x1, y1, x2, y2 float64
+	cx, cy, r      float64
+	fx, fy         float64
+	stops          []svgStop
+}
+
+// gradient resolves a gradient element by ID, inheriting attributes and stops through href.
+func (r *svgRenderer) gradient(id string, depth int) *svgGradient {
+	n := r.doc.ids[id]
+	if n == nil || depth > svgMaxUseDepth || (n.name != "linearGradient" && n.name != "radialGradient") {
+		return nil
+	}
+
+	g := &svgGradient{radial: n.name == "radialGradient", transform: svgIdentity,
+		x2: 1, cx: 0.5, cy: 0.5, r: 0.5, fx: math.NaN(), fy: math.NaN()}
+	var base *svgGradient
+	if href := n.attrs["href"]; href != "" {
+		base = r.gradient(strings.TrimPrefix(href, "#"), depth+1)
+		if base != nil {
+			*g = *base
+			g.radial = n.name == "radialGradient"
+		}
+	}
+
+	if v, ok := n.attrs["gradientUnits"]; ok {
+		g.userSpace = v == "userSpaceOnUse"
+	}
+	if v, ok := n.attrs["gradientTransform"]; ok {
+		g.transform = parseTransform(v)
+	}
+	coord := func(name string, dst *float64) {
+		v, ok := n.attrs[name]
+		if !ok {
+			return
+		}
+		v = strings.TrimSpace(v)
+		if strings.HasSuffix(v, "%") {
+			f, _ := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+			*dst = f / 100
+			return
+		}
+		*dst = parseLength(v, 1)
+	}
+	coord("x1", &g.x1)
+	coord("y1", &g.y1)
+	coord("x2", &g.x2)
+	coord("y2", &g.y2)
+	coord("cx", &g.cx)
+	coord("cy", &g.cy)
+	coord("r", &g.r)
+	coord("fx", &g.fx)
+	coord("fy", &g.fy)
+
+	var stops []svgStop
+	for _, c := range n.children {
+		if c.name != "stop" {
+			continue
+		}
+		props := r.properties(c)
+		offset := 0.0
+		coord := strings.TrimSpace(props["offset"])
+		if strings.HasSuffix(coord, "%") {
+			f, _ := strconv.ParseFloat(strings.TrimSuffix(coord, "%"), 64)
+			offset = f / 100
+		} else {
+			offset, _ = strconv.ParseFloat(coord, 64)
+		}
+		col, ok := parseSVGColor(props["stop-color"])
+		if !ok {
+			col = color.NRGBA{A: 255}
+		}
+		if v, err := strconv.ParseFloat(strings.TrimSpace(props["stop-opacity"]), 64); err == nil {
+			col.A = uint8(math.Max(0, math.Min(1, v)) * float64(col.A))
+		}
+		// Offsets must be non-decreasing
+		offset = math.Max(0, math.Min(1, offset))
+		if len(stops) > 0 && offset < stops[len(stops)-1].offset {
+			offset = stops[len(stops)-1].offset
+		}
+		stops = append(stops, svgStop{offset: offset, c: col})
+	}
+	if len(stops) > 0 {
+		g.stops = stops
+	}
+	if len(g.stops) == 0 {
+		return nil
+	}
+	return g
+}
+
+// colorAt returns the gradient color at a user-space point of a shape with the given bounds.
+func (g *svgGradient) colorAt(x, y float64, bbox [4]float64) color.NRGBA {
+	// Into gradient space: undo the bounding box mapping and gradientTransform
+	if !g.userSpace {
+		bw, bh := bbox[2]-bbox[0], bbox[3]-bbox[1]
+		if bw == 0 || bh == 0 {
+			return g.stops[len(g.stops)-1].c
+		}
+		x, y = (x-bbox[0])/bw, (y-bbox[1])/bh
+	}
+	p := g.transform.invert().apply(x, y)
+
+	var t float64
+	if g.radial {
+		fx, fy := g.fx, g.fy
+		if math.IsNaN(fx) {
+			fx = g.cx
+		}
+		if math.IsNaN(fy) {
+			fy = g.cy
+		}
+		if g.r <= 0 {
+			return g.stops[len(g.stops)-1].c
+		}
+		// Distance ratio from the focal point towards the circle edge
+		dx, dy := p.x-fx, p.y-fy
+		d := math.Hypot(dx, dy)
+		if d == 0 {
+			t = 0
+		} else {
+			ux, uy := dx/d, dy/d
+			ox, oy := fx-g.cx, fy-g.cy
+			bq := ox*ux + oy*uy
+			cq := ox*ox + oy*oy - g.r*g.r
+			disc := bq*bq - cq
+			if disc < 0 {
+				disc = 0
+			}
+			edge := -bq + math.Sqrt(disc)
+			if edge <= 0 {
+				t = 1
+			} else {
+				t = d / edge
+			}
+		}
+	} else {
+		dx, dy := g.x2-g.x1, g.y2-g.y1
+		l2 := dx*dx + dy*dy
+		if l2 == 0 {
+			return g.stops[len(g.stops)-1].c
+		}
+		t = ((p.x-g.x1)*dx + (p.y-g.y1)*dy) / l2
+	}
+	return g.stopColor(t)
+}
+
+// stopColor interpolates the stops at t with pad spread.
+func (g *svgGradient) stopColor(t float64) color.NRGBA {
+	stops := g.stops
+	if t <= stops[0].offset {
+		return stops[0].c
+	}
+	for i := 1; i < len(stops); i++ {
+		if t <= stops[i].offset {
+			a, b := stops[i-1], stops[i]
+			span := b.offset - a.offset
+			if span <= 0 {
+				return b.c
+			}
+			f := (t - a.offset) / span
+			mix := func(x, y uint8) uint8 { return uint8(float64(x)*(1-f) + float64(y)*f + 0.5) }
+			return color.NRGBA{mix(a.c.R, b.c.R), mix(a.c.G, b.c.G), mix(a.c.B, b.c.B), mix(a.c.A, b.c.A)}
+		}
+	}
+	return stops[len(stops)-1].c
+}
+
+// parseLength parses an SVG length in user units; percentages are relative to ref.
+func parseLength(s string, ref float64) float64 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	unit := 1.0
+	for _, u := range []struct {
+		suffix string
+		scale  float64
+	}{{"%", ref / 100}, {"px", 1}, {"pt", 4.0 / 3}, {"pc", 16}, {"mm", 96 / 25.4}, {"cm", 96 / 2.54}, {"in", 96}, {"em", 16}, {"ex", 8}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSuffix(s, u.suffix), u.scale
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return v * unit
+}
+
+// parseSVGColor parses CSS color syntax: #rgb, #rgba, #rrggbb, #rrggbbaa, rgb(), rgba() and common names.
+func parseSVGColor(s string) (color.NRGBA, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return color.NRGBA{}, false
+	}
+	if strings.HasPrefix(s, "#") {
+		hex := s[1:]
+		if len(hex) == 3 || len(hex) == 4 {
+			var b strings.Builder
+			for _, c := range hex {
+				b.WriteRune(c)
+				b.WriteRune(c)
+			}
+			hex = b.String()
+		}
+		c, err := ParseHexColor("#" + hex)
+		return c, err == nil
+	}
+	if strings.HasPrefix(s, "rgb") {
+		open, end := strings.IndexByte(s, '('), strings.IndexByte(s, ')')
+		if open < 0 || end < open {
+			return color.NRGBA{}, false
+		}
+		parts := strings.FieldsFunc(s[open+1:end], func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+		if len(parts) < 3 {
+			return color.NRGBA{}, false
+		}
+		ch := func(p string, scale float64) uint8 {
+			if strings.HasSuffix(p, "%") {
+				f, _ := strconv.ParseFloat(strings.TrimSuffix(p, "%"), 64)
+				return uint8(math.Max(0, math.Min(255, f*2.55+0.5)))
+			}
+			f, _ := strconv.ParseFloat(p, 64)
+			return uint8(math.Max(0, math.Min(255, f*scale+0.5)))
+		}
+		c := color.NRGBA{ch(parts[0], 1), ch(parts[1], 1), ch(parts[2], 1), 255}
+		if len(parts) >= 4 {
+			c.A = ch(parts[3], 255)
+		}
+		return c, true
+	}
+	if c, ok := svgNamedColors[s]; ok {
+		return c, true
+	}
+	return color.NRGBA{}, false
+}
+
+// svgNamedColors holds the CSS color keywords commonly found in icons.
+var svgNamedColors = map[string]color.NRGBA{
+	"transparent": {0, 0, 0, 0},
+	"black":       {0, 0, 0, 255},
+	"white":       {255, 255, 255, 255},
+	"red":         {255, 0, 0, 255},
+	"green":       {0, 128, 0, 255},
+	"lime":        {0, 255, 0, 255},
+	"blue":        {0, 0, 255, 255},
+	"yellow":      {255, 255, 0, 255},
+	"cyan":        {0, 255, 255, 255},
+	"aqua":        {0, 255, 255, 255},
+	"magenta":     {255, 0, 255, 255},
+	"fuchsia":     {255, 0, 255, 255},
+	"gray":        {128, 128, 128, 255},
+	"grey":        {128, 128, 128, 255},
+	"silver":      {192, 192, 192, 255},
+	"maroon":      {128, 0, 0, 255},
+	"olive":       {128, 128, 0, 255},
+	"navy":        {0, 0, 128, 255},
+	"purple":      {128, 0, 128, 255},
+	"teal":        {0, 128, 128, 255},
+	"orange":      {255, 165, 0, 255},
+	"pink":        {255, 192, 203, 255},
+	"brown":       {165, 42, 42, 255},
+	"gold":        {255, 215, 0, 255},
+	"darkgray":    {169, 169, 169, 255},
+	"darkgrey":    {169, 169, 169, 255},
+	"lightgray":   {211, 211, 211, 255},
+	"lightgrey":   {211, 211, 211, 255},
+	"dimgray":     {105, 105, 105, 255},
+	"whitesmoke":  {245, 245, 245, 255},
+	"gainsboro":   {220, 220, 220, 255},
+	"crimson":     {220, 20, 60, 255},
+	"tomato":      {255, 99, 71, 255},
+	"coral":       {255, 127, 80, 255},
+	"indigo":      {75, 0, 130, 255},
+	"violet":      {238, 130, 238, 255},
+	"skyblue":     {135, 206, 235, 255},
+	"steelblue":   {70, 130, 180, 255},
+	"royalblue":   {65, 105, 225, 255},
+	"dodgerblue":  {30, 144, 255, 255},
+	"darkblue":    {0, 0, 139, 255},
+	"darkgreen":   {0, 100, 0, 255},
+	"darkred":     {139, 0, 0, 255},
+	"limegreen":   {50, 205, 50, 255},
+	"seagreen":    {46, 139, 87, 255},
+	"forestgreen": {34, 139, 34, 255},
+}
@@ -0,0 +1,531 @@
+package graphics
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// svgMatrix is a 2D affine transform {a, b, c, d, e, f}:
+// x' = a*x + c*y + e, y' = b*x + d*y + f.
+type svgMatrix [6]float64
+
+// svgIdentity is the identity transform.
+var svgIdentity = svgMatrix{1, 0, 0, 1, 0, 0}
+
+// apply transforms a point.
+func (m svgMatrix) apply(x, y float64) svgPoint {
+	return svgPoint{m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]}
+}
+
+// mul returns m*n: n is applied first, then m.
+func (m svgMatrix) mul(n svgMatrix) svgMatrix {
+	return svgMatrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+// invert returns the inverse transform, or identity for singular matrices.
+func (m svgMatrix) invert() svgMatrix {
+	det := m[0]*m[3] - m[1]*m[2]
+	if det == 0 {
+		return svgIdentity
+	}
+	return svgMatrix{
+		m[3] / det, -m[1] / det,
+		-m[2] / det, m[0] / det,
+		(m[2]*m[5] - m[3]*m[4]) / det,
+		(m[1]*m[4] - m[0]*m[5]) / det,
+	}
+}
+
+// scale returns the average linear scale factor of the transform.
+func (m svgMatrix) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+// parseTransform parses an SVG transform attribute.
+func parseTransform(s string) svgMatrix {
+	m := svgIdentity
+	for {
+		open := strings.IndexByte(s, '(')
+		close := strings.IndexByte(s, ')')
+		if open < 0 || close < open {
+			return m
+		}
+		name := strings.TrimSpace(strings.Trim(s[:open], ", \t\n"))
+		args := parseNumberList(s[open+1 : close])
+		s = s[close+1:]
+
+		arg := func(i int, def float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return def
+		}
+
+		var t svgMatrix
+		switch name {
+		case "matrix":
+			if len(args) != 6 {
+				continue
+			}
+			copy(t[:], args)
+		case "translate":
+			t = svgMatrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			sx := arg(0, 1)
+			t = svgMatrix{sx, 0, 0, arg(1, sx), 0, 0}
+		case "rotate":
+			a := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			sin, cos := math.Sincos(a)
+			t = svgMatrix{1, 0, 0, 1, cx, cy}.
+				mul(svgMatrix{cos, sin, -sin, cos, 0, 0}).
+				mul(svgMatrix{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			t = svgMatrix{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = svgMatrix{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			continue
+		}
+		m = m.mul(t)
+	}
+}
+
+// parseNumberList parses whitespace/comma separated numbers.
+func parseNumberList(s string) []float64 {
+	var nums []float64
+	sc := svgScanner{s: s}
+	for {
+		v, ok := sc.number()
+		if !ok {
+			return nums
+		}
+		nums = append(nums, v)
+	}
+}
+
+// svgPolyline is a flattened subpath in device space.
+type svgPolyline struct {
+	points []svgPoint
+	closed bool
+}
+
+// svgPathBuilder flattens user-space path segments into device-space polylines.
+type svgPathBuilder struct {
+	m      svgMatrix
+	lines  []svgPolyline
+	cur    *svgPolyline
+	bbox   [4]float64 // User-space bounds: minX, minY, maxX, maxY
+	hasBox bool
+}
+
+func newPathBuilder(m svgMatrix) *svgPathBuilder {
+	return &svgPathBuilder{m: m}
+}
+
+// extend grows the user-space bounding box.
+func (b *svgPathBuilder) extend(x, y float64) {
+	if !b.hasBox {
+		b.bbox = [4]float64{x, y, x, y}
+		b.hasBox = true
+		return
+	}
+	b.bbox[0] = math.Min(b.bbox[0], x)
+	b.bbox[1] = math.Min(b.bbox[1], y)
+	b.bbox[2] = math.Max(b.bbox[2], x)
+	b.bbox[3] = math.Max(b.bbox[3], y)
+}
+
+func (b *svgPathBuilder) moveTo(x, y float64) {
+	b.lines = append(b.lines, svgPolyline{points: []svgPoint{b.m.apply(x, y)}})
+	b.cur = &b.lines[len(b.lines)-1]
+	b.extend(x, y)
+}
+
+func (b *svgPathBuilder) lineTo(x, y float64) {
+	if b.cur == nil {
+		b.moveTo(x, y)
+		return
+	}
+	b.cur.points = append(b.cur.points, b.m.apply(x, y))
+	b.extend(x, y)
+}
+
+// cubicTo flattens a cubic Bézier from the current point.
+func (b *svgPathBuilder) cubicTo(x1, y1, x2, y2, x, y float64) {
+	if b.cur == nil {
+		b.moveTo(x1, y1)
+	}
+	p0 := b.cur.points[len(b.cur.points)-1]
+	p1, p2, p3 := b.m.apply(x1, y1), b.m.apply(x2, y2), b.m.apply(x, y)
+
+	n := flattenSteps(math.Hypot(p1.x-p0.x, p1.y-p0.y) + math.Hypot(p2.x-p1.x, p2.y-p1.y) + math.Hypot(p3.x-p2.x, p3.y-p2.y))
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		mt := 1 - t
+		b.cur.points = append(b.cur.points, svgPoint{
+			mt*mt*mt*p0.x + 3*mt*mt*t*p1.x + 3*mt*t*t*p2.x + t*t*t*p3.x,
+			mt*mt*mt*p0.y + 3*mt*mt*t*p1.y + 3*mt*t*t*p2.y + t*t*t*p3.y,
+		})
+	}
+	b.extend(x1, y1)
+	b.extend(x2, y2)
+	b.extend(x, y)
+}
+
+// quadTo flattens a quadratic Bézier from the current point.
+func (b *svgPathBuilder) quadTo(x1, y1, x, y float64) {
+	if b.cur == nil {
+		b.moveTo(x1, y1)
+	}
+	p0 := b.cur.points[len(b.cur.points)-1]
+	p1, p2 := b.m.apply(x1, y1), b.m.apply(x, y)
+
+	n := flattenSteps(math.Hypot(p1.x-p0.x, p1.y-p0.y) + math.Hypot(p2.x-p1.x, p2.y-p1.y))
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		mt := 1 - t
+		b.cur.points = append(b.cur.points, svgPoint{
+			mt*mt*p0.x + 2*mt*t*p1.x + t*t*p2.x,
+			mt*mt*p0.y + 2*mt*t*p1.y + t*t*p2.y,
+		})
+	}
+	b.extend(x1, y1)
+	b.extend(x, y)
+}
+
+// close closes the current subpath; the next segment starts a new one at the same point.
+func (b *svgPathBuilder) close() {
+	if b.cur == nil {
+		return
+	}
+	b.cur.closed = true
+	first := b.cur.points[0]
+	b.cur.points = append(b.cur.points, first)
+	b.cur = nil
+}
+
+// polygons returns all subpaths as polygons for filling (open subpaths are closed implicitly).
+func (b *svgPathBuilder) polygons() []svgPolygon {
+	polys := make([]svgPolygon, 0, len(b.lines))
+	for _, l := range b.lines {
+		polys = append(polys, svgPolygon(l.points))
+	}
+	return polys
+}
+
+// flattenSteps returns the number of line segments for a curve of the given device length.
+func flattenSteps(length float64) int {
+	n := int(math.Ceil(length / 2))
+	if n < 1 {
+		return 1
+	}
+	if n > 128 {
+		return 128
+	}
+	return n
+}
+
+// arcTo appends an SVG elliptical arc from (x0, y0) as cubic Béziers.
+func (b *svgPathBuilder) arcTo(x0, y0, rx, ry, xRot float64, largeArc, sweep bool, x, y float64) {
+	if x0 == x && y0 == y {
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		b.lineTo(x, y)
+		return
+	}
+
+	// Endpoint to center parameterization (SVG 1.1 appendix F.6.5)
+	sinPhi, cosPhi := math.Sincos(xRot * math.Pi / 180)
+	dx, dy := (x0-x)/2, (y0-y)/2
+	x1p := cosPhi*dx + sinPhi*dy
+	y1p := -sinPhi*dx + cosPhi*dy
+
+	// Scale up radii that are too small to span the endpoints
+	if lambda := x1p*x1p/(rx*rx) + y1p*y1p/(ry*ry); lambda > 1 {
+		s := math.Sqrt(lambda)
+		rx, ry = rx*s, ry*s
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1p*y1p - ry*ry*x1p*x1p
+	den := rx*rx*y1p*y1p + ry*ry*x1p*x1p
+	coef := 0.0
+	if den != 0 && num > 0 {
+		coef = math.Sqrt(num / den)
+	}
+	if largeArc == sweep {
+		coef = -coef
+	}
+	cxp := coef * rx * y1p / ry
+	cyp := -coef * ry * x1p / rx
+	cx := cosPhi*cxp - sinPhi*cyp + (x0+x)/2
+	cy := sinPhi*cxp + cosPhi*cyp + (y0+y)/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta1 := angle(1, 0, (x1p-cxp)/rx, (y1p-cyp)/ry)
+	delta := angle((x1p-cxp)/rx, (y1p-cyp)/ry, (-x1p-cxp)/rx, (-y1p-cyp)/ry)
+	if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	} else if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	}
+
+	// Split into segments of at most 90 degrees, each approximated by one cubic
+	segments := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	step := delta / float64(segments)
+	k := 4.0 / 3 * math.Tan(step/4)
+	point := func(t float64) (float64, float64) {
+		s, c := math.Sincos(t)
+		return cx + rx*c*cosPhi - ry*s*sinPhi, cy + rx*c*sinPhi + ry*s*cosPhi
+	}
+	deriv := func(t float64) (float64, float64) {
+		s, c := math.Sincos(t)
+		return -rx*s*cosPhi - ry*c*sinPhi, -rx*s*sinPhi + ry*c*cosPhi
+	}
+	t := theta1
+	for i := 0; i < segments; i++ {
+		ax, ay := point(t)
+		adx, ady := deriv(t)
+		bx, by := point(t + step)
+		bdx, bdy := deriv(t + step)
+		b.cubicTo(ax+k*adx, ay+k*ady, bx-k*bdx, by-k*bdy, bx, by)
+		t += step
+	}
+}
+
+// buildPathData feeds SVG path data ("d" attribute) into a builder.
+func buildPathData(b *svgPathBuilder, d string) error {
+	sc := svgScanner{s: d}
+	var cmd byte
+	var x, y, startX, startY float64
+	var lastCtrlX, lastCtrlY float64 // Reflected control point for S/T
+	var lastCmd byte
+
+	for {
+		sc.skipSeparators()
+		if sc.done() {
+			return nil
+		}
+		if c := sc.peek(); (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+			cmd = c
+			sc.pos++
+		} else if cmd == 0 {
+			return fmt.Errorf("path data must start with a command")
+		}
+
+		rel := cmd >= 'a'
+		ox, oy := 0.0, 0.0
+		if rel {
+			ox, oy = x, y
+		}
+		nums := func(n int) ([]float64, error) {
+			vals := make([]float64, n)
+			for i := range vals {
+				v, ok := sc.number()
+				if !ok {
+					return nil, fmt.Errorf("expected number in path data at %d", sc.pos)
+				}
+				vals[i] = v
+			}
+			return vals, nil
+		}
+
+		upper := cmd &^ 0x20
+		if b.cur == nil && upper != 'M' && upper != 'Z' {
+			// Drawing after closepath continues from the subpath start
+			b.moveTo(x, y)
+		}
+		switch upper {
+		case 'M':
+			v, err := nums(2)
+			if err != nil {
+				return err
+			}
+			x, y = ox+v[0], oy+v[1]
+			startX, startY = x, y
+			b.moveTo(x, y)
+			// Subsequent pairs are implicit lineto commands
+			if rel {
+				cmd = 'l'
+			} else {
+				cmd = 'L'
+			}
+		case 'L':
+			v, err := nums(2)
+			if err != nil {
+				return err
+			}
+			x, y = ox+v[0], oy+v[1]
+			b.lineTo(x, y)
+		case 'H':
+			v, err := nums(1)
+			if err != nil {
+				return err
+			}
+			x = ox + v[0]
+			b.lineTo(x, y)
+		case 'V':
+			v, err := nums(1)
+			if err != nil {
+				return err
+			}
+			y = oy + v[0]
+			b.lineTo(x, y)
+		case 'C':
+			v, err := nums(6)
+			if err != nil {
+				return err
+			}
+			b.cubicTo(ox+v[0], oy+v[1], ox+v[2], oy+v[3], ox+v[4], oy+v[5])
+			lastCtrlX, lastCtrlY = ox+v[2], oy+v[3]
+			x, y = ox+v[4], oy+v[5]
+		case 'S':
+			v, err := nums(4)
+			if err != nil {
+				return err
+			}
+			c1x, c1y := x, y
+			if lastCmd == 'C' || lastCmd == 'S' {
+				c1x, c1y = 2*x-lastCtrlX, 2*y-lastCtrlY
+			}
+			b.cubicTo(c1x, c1y, ox+v[0], oy+v[1], ox+v[2], oy+v[3])
+			lastCtrlX, lastCtrlY = ox+v[0], oy+v[1]
+			x, y = ox+v[2], oy+v[3]
+		case 'Q':
+			v, err := nums(4)
+			if err != nil {
+				return err
+			}
+			b.quadTo(ox+v[0], oy+v[1], ox+v[2], oy+v[3])
+			lastCtrlX, lastCtrlY = ox+v[0], oy+v[1]
+			x, y = ox+v[2], oy+v[3]
+		case 'T':
+			v, err := nums(2)
+			if err != nil {
+				return err
+			}
+			cx, cy := x, y
+			if lastCmd == 'Q' || lastCmd == 'T' {
+				cx, cy = 2*x-lastCtrlX, 2*y-lastCtrlY
+			}
+			b.quadTo(cx, cy, ox+v[0], oy+v[1])
+			lastCtrlX, lastCtrlY = cx, cy
+			x, y = ox+v[0], oy+v[1]
+		case 'A':
+			v, err := nums(3)
+			if err != nil {
+				return err
+			}
+			large, ok1 := sc.flag()
+			sweep, ok2 := sc.flag()
+			end, err := nums(2)
+			if err != nil || !ok1 || !ok2 {
+				return fmt.Errorf("invalid arc in path data at %d", sc.pos)
+			}
+			nx, ny := ox+end[0], oy+end[1]
+			b.arcTo(x, y, v[0], v[1], v[2], large, sweep, nx, ny)
+			x, y = nx, ny
+		case 'Z':
+			b.close()
+			x, y = startX, startY
+		default:
+			return fmt.Errorf("unknown path command %q", cmd)
+		}
+		lastCmd = upper
+	}
+}
+
+// svgScanner tokenizes numbers in path data and attribute lists.
+type svgScanner struct {
+	s   string
+	pos int
+}
+
+func (sc *svgScanner) done() bool { return sc.pos >= len(sc.s) }
+
+func (sc *svgScanner) peek() byte { return sc.s[sc.pos] }
+
+func (sc *svgScanner) skipSeparators() {
+	for !sc.done() {
+		switch sc.peek() {
+		case ' ', '\t', '\n', '\r', ',':
+			sc.pos++
+		default:
+			return
+		}
+	}
+}
+
+// number reads the next number ("-1.5e3", ".5", "1.5.5" is two numbers).
+func (sc *svgScanner) number() (float64, bool) {
+	sc.skipSeparators()
+	start := sc.pos
+	if !sc.done() && (sc.peek() == '-' || sc.peek() == '+') {
+		sc.pos++
+	}
+	digits, dot := false, false
+	for !sc.done() {
+		c := sc.peek()
+		switch {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c == '.' && !dot:
+			dot = true
+		case (c == 'e' || c == 'E') && digits:
+			// Exponent, unless it's the start of another token
+			if sc.pos+1 < len(sc.s) {
+				n := sc.s[sc.pos+1]
+				if (n >= '0' && n <= '9') || n == '-' || n == '+' {
+					sc.pos += 2
+					for !sc.done() && sc.peek() >= '0' && sc.peek() <= '9' {
+						sc.pos++
+					}
+				}
+			}
+			return sc.parse(start, digits)
+		default:
+			return sc.parse(start, digits)
+		}
+		sc.pos++
+	}
+	return sc.parse(start, digits)
+}
+
+func (sc *svgScanner) parse(start int, digits bool) (float64, bool) {
+	if !digits {
+		sc.pos = start
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(sc.s[start:sc.pos], 64)
+	return v, err == nil
+}
+
+// flag reads a single arc flag digit, which may not be separated from the next number.
+func (sc *svgScanner) flag() (bool, bool) {
+	sc.skipSeparators()
+	if sc.done() {
+		return false, false
+	}
+	switch sc.peek() {
+	case '0':
+		sc.pos++
+		return false, true
+	case '1':
+		sc.pos++
+		return true, true
+	}
+	return false, false
+}
@@ -0,0 +1,281 @@
+package graphics
+
+import (
+	"math"
+	"sort"
+)
+
+// svgSubsamples is the number of sub-scanlines per pixel row; horizontal
+// coverage is computed exactly, so this only affects vertical anti-aliasing.
+const svgSubsamples = 5
+
+// svgPoint is a point in device (pixel) space.
+type svgPoint struct{ x, y float64 }
+
+// svgPolygon is a closed, flattened subpath.
+type svgPolygon []svgPoint
+
+// svgCoverage is a per-pixel coverage buffer in the range 0..1.
+type svgCoverage struct {
+	w, h int
+	a    []float64
+}
+
+// rasterizePolygons computes anti-aliased coverage of polygons under a fill rule.
+func rasterizePolygons(polys []svgPolygon, w, h int, evenOdd bool) *svgCoverage {
+	cov := &svgCoverage{w: w, h: h, a: make([]float64, w*h)}
+
+	type edge struct {
+		x0, y0, x1, y1 float64
+		dir            int
+	}
+	var edges []edge
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, poly := range polys {
+		n := len(poly)
+		if n < 3 {
+			continue
+		}
+		for i := 0; i < n; i++ {
+			p, q := poly[i], poly[(i+1)%n]
+			if p.y == q.y {
+				continue
+			}
+			e := edge{p.x, p.y, q.x, q.y, 1}
+			if p.y > q.y {
+				e = edge{q.x, q.y, p.x, p.y, -1}
+			}
+			edges = append(edges, e)
+			minY = math.Min(minY, e.y0)
+			maxY = math.Max(maxY, e.y1)
+		}
+	}
+	if len(edges) == 0 {
+		return cov
+	}
+
+	type crossing struct {
+		x   float64
+		dir int
+	}
+	var xs []crossing
+	rowStart := int(math.Max(0, math.Floor(minY)))
+	rowEnd := int(math.Min(float64(h), math.Ceil(maxY)))
+	weight := 1.0 / svgSubsamples
+
+	for row := rowStart; row < rowEnd; row++ {
+		for s := 0; s < svgSubsamples; s++ {
+			y := float64(row) + (float64(s)+0.5)/svgSubsamples
+			xs = xs[:0]
+			for _, e := range edges {
+				if y < e.y0 || y >= e.y1 {
+					continue
+				}
+				t := (y - e.y0) / (e.y1 - e.y0)
+				xs = append(xs, crossing{e.x0 + t*(e.x1-e.x0), e.dir})
+			}
+			if len(xs) < 2 {
+				continue
+			}
+			sort.Slice(xs, func(i, j int) bool { return xs[i].x < xs[j].x })
+
+			winding := 0
+			for i := 0; i < len(xs)-1; i++ {
+				winding += xs[i].dir
+				inside := winding != 0
+				if evenOdd {
+					inside = winding%2 != 0
+				}
+				if inside {
+					cov.addSpan(row, xs[i].x, xs[i+1].x, weight)
+				}
+			}
+		}
+	}
+	return cov
+}
+
+// addSpan adds weight times the horizontal coverage of [x0, x1) to a pixel row.
+func (c *svgCoverage) addSpan(row int, x0, x1, weight float64) {
+	x0 = math.Max(x0, 0)
+	x1 = math.Min(x1, float64(c.w))
+	if x1 <= x0 {
+		return
+	}
+	line := c.a[row*c.w : (row+1)*c.w]
+	first, last := int(x0), int(x1)
+	if first == last {
+		line[first] += (x1 - x0) * weight
+		return
+	}
+	line[first] += (float64(first+1) - x0) * weight
+	for x := first + 1; x < last && x < c.w; x++ {
+		line[x] += weight
+	}
+	if last < c.w {
+		line[last] += (x1 - float64(last)) * weight
+	}
+}
+
+// at returns the clamped coverage of a pixel.
+func (c *svgCoverage) at(x, y int) float64 {
+	v := c.a[y*c.w+x]
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// strokePolylines converts polylines into polygons covering their stroke.
+// Every polygon is oriented the same way, so a nonzero fill gives their union.
+func strokePolylines(lines []svgPolyline, width float64, cap, join string, miterLimit float64) []svgPolygon {
+	hw := width / 2
+	if hw <= 0 {
+		return nil
+	}
+
+	var polys []svgPolygon
+	add := func(p svgPolygon) {
+		polys = append(polys, orientPolygon(p))
+	}
+
+	for _, line := range lines {
+		pts := dedupePoints(line.points)
+		if len(pts) == 1 {
+			// Zero-length subpath: only round and square caps are visible
+			switch cap {
+			case "round":
+				add(svgCircle(pts[0], hw))
+			case "square":
+				p := pts[0]
+				add(svgPolygon{{p.x - hw, p.y - hw}, {p.x + hw, p.y - hw}, {p.x + hw, p.y + hw}, {p.x - hw, p.y + hw}})
+			}
+			continue
+		}
+		if len(pts) < 2 {
+			continue
+		}
+
+		n := len(pts) - 1
+		for i := 0; i < n; i++ {
+			a, b := pts[i], pts[i+1]
+			dx, dy := b.x-a.x, b.y-a.y
+			l := math.Hypot(dx, dy)
+			ux, uy := dx/l, dy/l
+			// Square caps extend the open ends by half the width
+			if !line.closed && cap == "square" {
+				if i == 0 {
+					a = svgPoint{a.x - ux*hw, a.y - uy*hw}
+				}
+				if i == n-1 {
+					b = svgPoint{b.x + ux*hw, b.y + uy*hw}
+				}
+			}
+			nx, ny := -uy*hw, ux*hw
+			add(svgPolygon{{a.x + nx, a.y + ny}, {b.x + nx, b.y + ny}, {b.x - nx, b.y - ny}, {a.x - nx, a.y - ny}})
+		}
+
+		// Joins between consecutive segments (and around the closing vertex)
+		joinAt := func(prev, v, next svgPoint) {
+			if join == "round" {
+				add(svgCircle(v, hw))
+				return
+			}
+			add(joinPolygon(prev, v, next, hw, join == "miter" || join == "", miterLimit))
+		}
+		for i := 1; i < n; i++ {
+			joinAt(pts[i-1], pts[i], pts[i+1])
+		}
+		if line.closed && n >= 2 {
+			joinAt(pts[n-1], pts[n], pts[1])
+		}
+
+		if !line.closed && cap == "round" {
+			add(svgCircle(pts[0], hw))
+			add(svgCircle(pts[n], hw))
+		}
+	}
+	return polys
+}
+
+// joinPolygon fills the outer wedge of a join: a miter if allowed, otherwise a bevel.
+func joinPolygon(prev, v, next svgPoint, hw float64, miter bool, miterLimit float64) svgPolygon {
+	d1x, d1y := v.x-prev.x, v.y-prev.y
+	d2x, d2y := next.x-v.x, next.y-v.y
+	l1, l2 := math.Hypot(d1x, d1y), math.Hypot(d2x, d2y)
+	d1x, d1y, d2x, d2y = d1x/l1, d1y/l1, d2x/l2, d2y/l2
+
+	// The outer side is opposite the turn direction
+	side := 1.0
+	if d1x*d2y-d1y*d2x > 0 {
+		side = -1
+	}
+	p1 := svgPoint{v.x - d1y*hw*side, v.y + d1x*hw*side}
+	p2 := svgPoint{v.x - d2y*hw*side, v.y + d2x*hw*side}
+
+	if miter {
+		cosTheta := -(d1x*d2x + d1y*d2y) // angle between the segments
+		if cosTheta < 1 {
+			ratio := 1 / math.Sin(math.Acos(cosTheta)/2)
+			if ratio <= miterLimit {
+				// Miter tip: along the bisector of the two offset normals
+				bx, by := (p1.x+p2.x)/2-v.x, (p1.y+p2.y)/2-v.y
+				bl := math.Hypot(bx, by)
+				if bl > 0 {
+					tip := svgPoint{v.x + bx/bl*hw*ratio, v.y + by/bl*hw*ratio}
+					return svgPolygon{v, p1, tip, p2}
+				}
+			}
+		}
+	}
+	return svgPolygon{v, p1, p2}
+}
+
+// svgCircle approximates a circle as a polygon.
+func svgCircle(c svgPoint, r float64) svgPolygon {
+	n := int(math.Ceil(r * 2))
+	if n < 8 {
+		n = 8
+	}
+	if n > 64 {
+		n = 64
+	}
+	poly := make(svgPolygon, n)
+	for i := range poly {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		poly[i] = svgPoint{c.x + r*math.Cos(a), c.y + r*math.Sin(a)}
+	}
+	return poly
+}
+
+// orientPolygon returns the polygon with a positive signed area.
+func orientPolygon(p svgPolygon) svgPolygon {
+	area := 0.0
+	for i := range p {
+		q := p[(i+1)%len(p)]
+		area += p[i].x*q.y - q.x*p[i].y
+	}
+	if area >= 0 {
+		return p
+	}
+	r := make(svgPolygon, len(p))
+	for i := range p {
+		r[i] = p[len(p)-1-i]
+	}
+	return r
+}
+
+// dedupePoints drops consecutive duplicate points.
+func dedupePoints(pts []svgPoint) []svgPoint {
+	out := make([]svgPoint, 0, len(pts))
+	for _, p := range pts {
+		if len(out) > 0 {
+			last := out[len(out)-1]
+			if math.Abs(last.x-p.x) < 1e-9 && math.Abs(last.y-p.y) < 1e-9 {
+				continue
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
@@ -0,0 +1,247 @@
+package graphics
+
+import (
+	"math"
+	"testing"
+)
+
+// nearPoint reports whether two points are equal within rounding error.
+func nearPoint(a, b svgPoint) bool {
+	return math.Abs(a.x-b.x) < 1e-6 && math.Abs(a.y-b.y) < 1e-6
+}
+
+func TestParseNumberList(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want []float64
+	}{
+		{"", nil},
+		{"1 2,3", []float64{1, 2, 3}},
+		{"  -1.5e2 , +4 ", []float64{-150, 4}},
+		{"1.5.5", []float64{1.5, 0.5}},
+		{"1-2", []float64{1, -2}},
+		{"1e3e", []float64{1000}},
+		{"2 x 3", []float64{2}},
+	} {
+		got := parseNumberList(tt.in)
+		if len(got) != len(tt.want) {
+			t.Errorf("parseNumberList(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("parseNumberList(%q) = %v, want %v", tt.in, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestBuildPathData(t *testing.T) {
+	pts := func(xy ...float64) []svgPoint {
+		var p []svgPoint
+		for i := 0; i+1 < len(xy); i += 2 {
+			p = append(p, svgPoint{xy[i], xy[i+1]})
+		}
+		return p
+	}
+
+	for _, tt := range []struct {
+		name   string
+		d      string
+		want   []svgPolyline
+		bbox   [4]float64
+		wantOK bool
+	}{
+		{
+			name:   "absolute lines",
+			d:      "M0 0 L10 0 L10 10 Z",
+			want:   []svgPolyline{{points: pts(0, 0, 10, 0, 10, 10, 0, 0), closed: true}},
+			bbox:   [4]float64{0, 0, 10, 10},
+			wantOK: true,
+		},
+		{
+			name:   "relative with implicit lineto",
+			d:      "m1 1 2 0 0 2z",
+			want:   []svgPolyline{{points: pts(1, 1, 3, 1, 3, 3, 1, 1), closed: true}},
+			bbox:   [4]float64{1, 1, 3, 3},
+			wantOK: true,
+		},
+		{
+			name:   "horizontal and vertical",
+			d:      "M2,2H8V6h-4v-2",
+			want:   []svgPolyline{{points: pts(2, 2, 8, 2, 8, 6, 4, 6, 4, 4)}},
+			bbox:   [4]float64{2, 2, 8, 6},
+			wantOK: true,
+		},
+		{
+			name: "drawing after close restarts at subpath start",
+			d:    "M0 0 L4 0 L4 4 Z L0 4",
+			want: []svgPolyline{
+				{points: pts(0, 0, 4, 0, 4, 4, 0, 0), closed: true},
+				{points: pts(0, 0, 0, 4)},
+			},
+			bbox:   [4]float64{0, 0, 4, 4},
+			wantOK: true,
+		},
+		{
+			name:   "compact numbers",
+			d:      "M.5.5L-.5-.5",
+			want:   []svgPolyline{{points: pts(0.5, 0.5, -0.5, -0.5)}},
+			bbox:   [4]float64{-0.5, -0.5, 0.5, 0.5},
+			wantOK: true,
+		},
+		{name: "no command", d: "10 10"},
+		{name: "missing coordinate", d: "M10"},
+		{name: "unknown command", d: "M0 0 X5 5"},
+		{name: "bad arc flag", d: "M0 0 A5 5 0 2 0 10 0"},
+		{name: "truncated curve", d: "M0 0 C1 1 2 2"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newPathBuilder(svgIdentity)
+			err := buildPathData(b, tt.d)
+			if !tt.wantOK {
+				if err == nil {
+					t.Fatalf("buildPathData(%q) = nil error, want one", tt.d)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildPathData(%q): %v", tt.d, err)
+			}
+			if len(b.lines) != len(tt.want) {
+				t.Fatalf("got %d subpaths %+v, want %d", len(b.lines), b.lines, len(tt.want))
+			}
+			for i, line := range b.lines {
+				want := tt.want[i]
+				if line.closed != want.closed || len(line.points) != len(want.points) {
+					t.Fatalf("subpath %d = %+v, want %+v", i, line, want)
+				}
+				for j := range line.points {
+					if !nearPoint(line.points[j], want.points[j]) {
+						t.Errorf("subpath %d point %d = %v, want %v", i, j, line.points[j], want.points[j])
+					}
+				}
+			}
+			if b.bbox != tt.bbox {
+				t.Errorf("bbox = %v, want %v", b.bbox, tt.bbox)
+			}
+		})
+	}
+}
+
+func TestBuildPathDataCurves(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		d    string
+		end  svgPoint
+	}{
+		{"cubic", "M0 0 C0 10 10 10 10 0", svgPoint{10, 0}},
+		{"smooth cubic", "M0 0 C0 5 5 5 5 0 S10 -5 10 0", svgPoint{10, 0}},
+		{"relative cubic", "M1 1 c0 4 4 4 4 0", svgPoint{5, 1}},
+		{"quadratic", "M0 0 Q5 10 10 0", svgPoint{10, 0}},
+		{"smooth quadratic", "M0 0 Q2.5 5 5 0 T10 0", svgPoint{10, 0}},
+		{"arc", "M0 0 A5 5 0 0 1 10 0", svgPoint{10, 0}},
+		{"arc with packed flags", "M0 0 a5 5 0 1010 0", svgPoint{10, 0}},
+		{"degenerate arc radius", "M0 0 A0 0 0 0 1 6 8", svgPoint{6, 8}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newPathBuilder(svgIdentity)
+			if err := buildPathData(b, tt.d); err != nil {
+				t.Fatalf("buildPathData(%q): %v", tt.d, err)
+			}
+			if len(b.lines) != 1 || len(b.lines[0].points) < 2 {
+				t.Fatalf("got subpaths %+v, want one curve", b.lines)
+			}
+			points := b.lines[0].points
+			if end := points[len(points)-1]; !nearPoint(end, tt.end) {
+				t.Errorf("curve ends at %v, want %v", end, tt.end)
+			}
+		})
+	}
+}
+
+func TestParseTransform(t *testing.T) {
+	for _, tt := range []struct {
+		transform string
+		in, want  svgPoint
+	}{
+		{"", svgPoint{3, 4}, svgPoint{3, 4}},
+		{"translate(10)", svgPoint{1, 1}, svgPoint{11, 1}},
+		{"translate(10, -5)", svgPoint{1, 1}, svgPoint{11, -4}},
+		{"scale(2)", svgPoint{1, 3}, svgPoint{2, 6}},
+		{"scale(2 3)", svgPoint{1, 1}, svgPoint{2, 3}},
+		{"rotate(90)", svgPoint{1, 0}, svgPoint{0, 1}},
+		{"rotate(180 5 5)", svgPoint{0, 0}, svgPoint{10, 10}},
+		{"matrix(1 0 0 1 7 8)", svgPoint{0, 0}, svgPoint{7, 8}},
+		{"skewX(45)", svgPoint{0, 1}, svgPoint{1, 1}},
+		{"skewY(45)", svgPoint{1, 0}, svgPoint{1, 1}},
+		{"translate(10) scale(2)", svgPoint{1, 1}, svgPoint{12, 2}},
+		{"scale(2),translate(10)", svgPoint{1, 1}, svgPoint{22, 2}},
+		{"matrix(1 2) translate(1)", svgPoint{0, 0}, svgPoint{1, 0}},
+		{"wobble(3) translate(0 1)", svgPoint{0, 0}, svgPoint{0, 1}},
+		{"translate(5", svgPoint{0, 0}, svgPoint{0, 0}},
+	} {
+		m := parseTransform(tt.transform)
+		if got := m.apply(tt.in.x, tt.in.y); !nearPoint(got, tt.want) {
+			t.Errorf("parseTransform(%q) maps %v to %v, want %v", tt.transform, tt.in, got, tt.want)
+		}
+		if got := m.invert().apply(tt.want.x, tt.want.y); !nearPoint(got, tt.in) {
+			t.Errorf("parseTransform(%q) inverse maps %v to %v, want %v", tt.transform, tt.want, got, tt.in)
+		}
+	}
+}
+
+func TestParseSVGRaster(t *testing.T) {
+	svg, err := ParseSVG([]byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 4 4">
+		<path d="M0 0H2V4H0Z" fill="#ff0000"/>
+		<g transform="translate(2 0)"><rect width="2" height="4" fill="blue"/></g>
+	</svg>`))
+	if err != nil {
+		t.Fatalf("ParseSVG: %v", err)
+	}
+	img := svg.Rasterize(8, 8)
+	if c := img.NRGBAAt(1, 4); c.R != 0xff || c.B != 0 || c.A != 0xff {
+		t.Errorf("left half = %v, want red", c)
+	}
+	if c := img.NRGBAAt(6, 4); c.B != 0xff || c.R != 0 || c.A != 0xff {
+		t.Errorf("translated right half = %v, want blue", c)
+	}
+}
+
+func TestParseSVGMalformed(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"not svg", `<html><body/></html>`},
+		{"zero size", `<svg viewBox="0 0 0 0"/>`},
+		{"negative size", `<svg width="-4" height="4" viewBox="0 0 -4 4"/>`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if svg, err := ParseSVG([]byte(tt.data)); err == nil {
+				t.Errorf("ParseSVG = %+v, want error", svg)
+			}
+		})
+	}
+
+	// Truncated documents must fail or render what they hold, never panic
+	doc := `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 24 24">
+		<style>.a{fill:url(#g);stroke:#000;stroke-width:2}</style>
+		<defs><linearGradient id="g" x2="1"><stop offset="0" stop-color="red"/><stop offset="1" stop-color="blue"/></linearGradient>
+		<radialGradient id="r" href="#g" r=".5"/></defs>
+		<g id="shape" transform="rotate(15 12 12) scale(0.9)">
+			<path class="a" d="M4 4h16v16H4z M8 8a4 4 0 1 0 8 0 4 4 0 1 0-8 0" fill-rule="evenodd"/>
+			<circle cx="12" cy="12" r="3" fill="url(#r)" stroke-linecap="round"/>
+		</g>
+		<use xlink:href="#shape" transform="translate(2 2)" opacity=".5"/>
+		<polyline points="1 1 5 9 9 1" stroke="green" stroke-linejoin="miter" fill="none"/>
+	</svg>`
+	// Every third prefix; each parse rasterizes a preview
+	for n := 0; n <= len(doc); n += 3 {
+		if svg, err := ParseSVG([]byte(doc[:n])); err == nil {
+			svg.Rasterize(16, 16)
+		}
+	}
+}
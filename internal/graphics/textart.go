@@ -0,0 +1,292 @@
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"strings"
+
+	"tooie-shelf/internal/sys"
+)
+
+// alphaThreshold is the minimum alpha (0-255) for a pixel to count as visible in text art.
+const alphaThreshold = 128
+
+// TextRenderer renders images as Unicode text art for terminals without image support.
+// Half-block mode packs two pixels per cell (▀/▄), braille mode packs 2x4 dots per cell.
+type TextRenderer struct {
+	Braille   bool // Use braille dots instead of half blocks
+	TrueColor bool // Emit 24-bit colors instead of the xterm 256-color palette
+}
+
+// NewTextRenderer creates a text renderer, detecting truecolor support from COLORTERM.
+func NewTextRenderer(braille bool) TextRenderer {
+	colorTerm := strings.ToLower(os.Getenv("COLORTERM"))
+	return TextRenderer{
+		Braille:   braille,
+		TrueColor: colorTerm == "truecolor" || colorTerm == "24bit",
+	}
+}
+
+// Name returns the protocol name.
+func (r TextRenderer) Name() string {
+	if r.Braille {
+		return ProtocolBraille
+	}
+	return ProtocolBlocks
+}
+
+// Render converts an image to rows of colored text.
+// Each row ends with a relative cursor move so the payload can be written at any position.
+// Width and Height are reported in pixels (cells * cellPx) so callers can center it like an image.
+func (r TextRenderer) Render(src image.Image, widthCells, heightCells int, cellPx sys.CellDim, style IconStyle) Payload {
+	scaled := prepareIcon(src, widthCells, heightCells, cellPx, style)
+	if scaled == nil {
+		return Payload{}
+	}
+	bounds := scaled.Bounds()
+
+	// Number of terminal cells the scaled icon covers
+	cols := (bounds.Dx() + cellPx.Width/2) / cellPx.Width
+	rows := (bounds.Dy() + cellPx.Height/2) / cellPx.Height
+	if cols < 1 {
+		cols = 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+
+	var lines []string
+	if r.Braille {
+		lines = r.renderBraille(ScaleImage(scaled, cols*2, rows*4), cols, rows)
+	} else {
+		lines = r.renderBlocks(ScaleImage(scaled, cols, rows*2), cols, rows)
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(line)
+		if i < len(lines)-1 {
+			// Next line: down one row, back to the starting column
+			fmt.Fprintf(&b, "\x1b[1B\x1b[%dD", cols)
+		}
+	}
+
+	return Payload{
+		Data:   b.String(),
+		Width:  cols * cellPx.Width,
+		Height: rows * cellPx.Height,
+	}
+}
+
+// Delete is a no-op: text art is overwritten by redraws.
+func (r TextRenderer) Delete(p Payload) string {
+	return ""
+}
+
+// ClearAll is a no-op for text art.
+func (r TextRenderer) ClearAll() string {
+	return ""
+}
+
+// renderBlocks renders an image of cols x rows*2 pixels using upper/lower half blocks.
+func (r TextRenderer) renderBlocks(img image.Image, cols, rows int) []string {
+	bounds := img.Bounds()
+	lines := make([]string, 0, rows)
+
+	for row := 0; row < rows; row++ {
+		var b strings.Builder
+		for col := 0; col < cols; col++ {
+			top := color.NRGBAModel.Convert(img.At(bounds.Min.X+col, bounds.Min.Y+row*2)).(color.NRGBA)
+			bottom := color.NRGBAModel.Convert(img.At(bounds.Min.X+col, bounds.Min.Y+row*2+1)).(color.NRGBA)
+			topVisible := top.A >= alphaThreshold
+			bottomVisible := bottom.A >= alphaThreshold
+
+			switch {
+			case topVisible && bottomVisible:
+				b.WriteString(r.fg(top) + r.bg(bottom) + "▀")
+			case topVisible:
+				b.WriteString("\x1b[49m" + r.fg(top) + "▀")
+			case bottomVisible:
+				b.WriteString("\x1b[49m" + r.fg(bottom) + "▄")
+			default:
+				b.WriteString("\x1b[0m ")
+			}
+		}
+		b.WriteString("\x1b[0m")
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// brailleDots maps a pixel offset (x, y) inside a 2x4 block to its braille dot bit.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// renderBraille renders an image of cols*2 x rows*4 pixels using braille patterns.
+// Opaque blocks are split into a bright (dots) and dark (background) half;
+// blocks with transparency draw their visible pixels as dots on the terminal background.
+func (r TextRenderer) renderBraille(img image.Image, cols, rows int) []string {
+	bounds := img.Bounds()
+	lines := make([]string, 0, rows)
+
+	for row := 0; row < rows; row++ {
+		var b strings.Builder
+		for col := 0; col < cols; col++ {
+			var px [8]color.NRGBA
+			var visible [8]bool
+			var lum [8]int
+			numVisible := 0
+			lumSum := 0
+
+			for dy := 0; dy < 4; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					i := dy*2 + dx
+					c := color.NRGBAModel.Convert(img.At(bounds.Min.X+col*2+dx, bounds.Min.Y+row*4+dy)).(color.NRGBA)
+					px[i] = c
+					if c.A >= alphaThreshold {
+						visible[i] = true
+						lum[i] = luminance(c)
+						lumSum += lum[i]
+						numVisible++
+					}
+				}
+			}
+
+			if numVisible == 0 {
+				b.WriteString("\x1b[0m ")
+				continue
+			}
+
+			var pattern rune
+			var fgSum, bgSum [3]int
+			fgCount, bgCount := 0, 0
+			mean := lumSum / numVisible
+
+			for dy := 0; dy < 4; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					i := dy*2 + dx
+					if !visible[i] {
+						continue
+					}
+					// With transparency, every visible pixel is a dot.
+					// Fully opaque blocks use dots for the brighter half.
+					if numVisible < 8 || lum[i] >= mean {
+						pattern |= brailleDots[dy][dx]
+						addColor(&fgSum, px[i])
+						fgCount++
+					} else {
+						addColor(&bgSum, px[i])
+						bgCount++
+					}
+				}
+			}
+
+			if bgCount > 0 {
+				b.WriteString(r.bg(avgColor(bgSum, bgCount)))
+			} else {
+				b.WriteString("\x1b[49m")
+			}
+			if fgCount > 0 {
+				b.WriteString(r.fg(avgColor(fgSum, fgCount)))
+			}
+			b.WriteRune(0x2800 + pattern)
+		}
+		b.WriteString("\x1b[0m")
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// fg returns the SGR sequence setting the foreground color.
+func (r TextRenderer) fg(c color.NRGBA) string {
+	if r.TrueColor {
+		return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("\x1b[38;5;%dm", ansi256(c))
+}
+
+// bg returns the SGR sequence setting the background color.
+func (r TextRenderer) bg(c color.NRGBA) string {
+	if r.TrueColor {
+		return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("\x1b[48;5;%dm", ansi256(c))
+}
+
+// luminance returns the approximate perceived brightness (0-255) of a color.
+func luminance(c color.NRGBA) int {
+	return (299*int(c.R) + 587*int(c.G) + 114*int(c.B)) / 1000
+}
+
+// addColor accumulates a color into an RGB sum.
+func addColor(sum *[3]int, c color.NRGBA) {
+	sum[0] += int(c.R)
+	sum[1] += int(c.G)
+	sum[2] += int(c.B)
+}
+
+// avgColor returns the average of an accumulated RGB sum.
+func avgColor(sum [3]int, n int) color.NRGBA {
+	return color.NRGBA{R: uint8(sum[0] / n), G: uint8(sum[1] / n), B: uint8(sum[2] / n), A: 255}
+}
+
+// cubeLevels are the channel values of the xterm 6x6x6 color cube.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// ansi256 returns the closest xterm 256-color palette index for a color,
+// choosing between the 6x6x6 color cube and the 24-step grayscale ramp.
+func ansi256(c color.NRGBA) int {
+	r, g, b := int(c.R), int(c.G), int(c.B)
+
+	// Nearest color cube entry
+	ri, gi, bi := cubeIndex(r), cubeIndex(g), cubeIndex(b)
+	cubeDist := sqDist(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	// Nearest grayscale ramp entry (8, 18, ..., 238)
+	avg := (r + g + b) / 3
+	grayIdx := (avg - 3) / 10
+	if grayIdx < 0 {
+		grayIdx = 0
+	}
+	if grayIdx > 23 {
+		grayIdx = 23
+	}
+	gray := 8 + grayIdx*10
+	grayDist := sqDist(r, g, b, gray, gray, gray)
+
+	if grayDist < cubeDist {
+		return 232 + grayIdx
+	}
+	return 16 + 36*ri + 6*gi + bi
+}
+
+// cubeIndex returns the index of the closest color cube level for a channel value.
+func cubeIndex(v int) int {
+	best := 0
+	for i, level := range cubeLevels {
+		if abs(v-level) < abs(v-cubeLevels[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// sqDist returns the squared RGB distance between two colors.
+func sqDist(r1, g1, b1, r2, g2, b2 int) int {
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	return dr*dr + dg*dg + db*db
+}
+
+// abs returns the absolute value of an int.
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,155 @@
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+// Icon theme modes accepted by style.icon_theme.
+const (
+	ThemeNone            = "none"
+	ThemeGrayscale       = "grayscale"
+	ThemeTint            = "tint"
+	ThemeDuotone         = "duotone"
+	ThemeMonochromeLayer = "monochrome-layer"
+)
+
+// Default theme palette, similar to Android's dark themed icons.
+var (
+	DefaultThemeDark  = color.NRGBA{R: 0x2b, G: 0x29, B: 0x30, A: 0xff}
+	DefaultThemeLight = color.NRGBA{R: 0xd0, G: 0xbc, B: 0xff, A: 0xff}
+)
+
+// IconTheme recolors icons after they are loaded and before they are rendered.
+type IconTheme struct {
+	Mode  string      // "", ThemeNone, ThemeGrayscale, ThemeTint, ThemeDuotone or ThemeMonochromeLayer
+	Tint  color.NRGBA // Color for ThemeTint
+	Dark  color.NRGBA // Shadows for duotone, background for monochrome-layer
+	Light color.NRGBA // Highlights for duotone, foreground for monochrome-layer
+}
+
+// ParseIconTheme parses a theme spec ("grayscale", "tint:#rrggbb", "duotone",
+// "monochrome-layer") with an optional [dark, light] palette.
+func ParseIconTheme(spec string, palette []string) (IconTheme, error) {
+	t := IconTheme{Mode: spec, Dark: DefaultThemeDark, Light: DefaultThemeLight}
+
+	if strings.HasPrefix(spec, ThemeTint+":") {
+		c, err := ParseHexColor(strings.TrimPrefix(spec, ThemeTint+":"))
+		if err != nil {
+			return IconTheme{}, err
+		}
+		t.Mode, t.Tint = ThemeTint, c
+	}
+
+	switch t.Mode {
+	case "", ThemeNone, ThemeGrayscale, ThemeTint, ThemeDuotone, ThemeMonochromeLayer:
+	default:
+		return IconTheme{}, fmt.Errorf("unknown icon theme %q", spec)
+	}
+
+	for i, s := range palette {
+		c, err := ParseHexColor(s)
+		if err != nil {
+			return IconTheme{}, err
+		}
+		switch i {
+		case 0:
+			t.Dark = c
+		case 1:
+			t.Light = c
+		}
+	}
+	return t, nil
+}
+
+// Active reports whether the theme changes icons at all.
+func (t IconTheme) Active() bool {
+	return t.Mode != "" && t.Mode != ThemeNone
+}
+
+// Apply recolors an icon. For ThemeMonochromeLayer, adaptive icons with a monochrome
+// layer are recomposed from it and masked to shape; other icons fall back to duotone.
+func (t IconTheme) Apply(src image.Image, adaptive *AdaptiveIcon, shape string) image.Image {
+	if src == nil || !t.Active() {
+		return src
+	}
+
+	var tone func(y uint8) color.NRGBA
+	switch t.Mode {
+	case ThemeGrayscale:
+		tone = func(y uint8) color.NRGBA {
+			return color.NRGBA{R: y, G: y, B: y}
+		}
+	case ThemeTint:
+		tone = func(y uint8) color.NRGBA {
+			return color.NRGBA{R: mul8(t.Tint.R, y), G: mul8(t.Tint.G, y), B: mul8(t.Tint.B, y)}
+		}
+	case ThemeMonochromeLayer:
+		if adaptive != nil && adaptive.Monochrome != nil {
+			return adaptive.ComposeMonochrome(shape, t.Dark, t.Light)
+		}
+	}
+	if tone == nil {
+		// Duotone, and the monochrome fallback for icons without a monochrome layer
+		tone = func(y uint8) color.NRGBA {
+			return lerpColor(t.Dark, t.Light, y)
+		}
+	}
+
+	// Vector icons are recolored each time they are rasterized, at the size rendered
+	if svg, ok := src.(*SVGIcon); ok {
+		return svg.Recolor(func(img *image.NRGBA) *image.NRGBA {
+			return mapLuminance(img, tone)
+		})
+	}
+	return mapLuminance(src, tone)
+}
+
+// ComposeMonochrome builds a themed icon: the monochrome layer tinted fg over a solid bg,
+// cropped to the viewport and masked like Compose.
+func (a *AdaptiveIcon) ComposeMonochrome(shape string, bg, fg color.NRGBA) image.Image {
+	if shape == "" {
+		shape = ShapeCircle
+	}
+
+	// The monochrome drawable is an alpha mask; its color is ignored
+	bounds := a.Monochrome.Bounds()
+	tinted := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.DrawMask(tinted, tinted.Bounds(), image.NewUniform(fg), image.Point{}, a.Monochrome, bounds.Min, draw.Src)
+
+	return ApplyShapeMask(a.composeLayers(solidImage(bg), tinted), shape)
+}
+
+// mapLuminance replaces each pixel's color by f(luminance), keeping its alpha.
+func mapLuminance(src image.Image, f func(y uint8) color.NRGBA) *image.NRGBA {
+	bounds := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			c := color.NRGBAModel.Convert(src.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			if c.A == 0 {
+				continue
+			}
+			out := f(uint8(luminance(c)))
+			out.A = c.A
+			dst.SetNRGBA(x, y, out)
+		}
+	}
+	return dst
+}
+
+// lerpColor interpolates between a and b by t/255.
+func lerpColor(a, b color.NRGBA, t uint8) color.NRGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8((int(x)*(255-int(t)) + int(y)*int(t)) / 255)
+	}
+	return color.NRGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: 255}
+}
+
+// mul8 multiplies two 8-bit channel values.
+func mul8(a, b uint8) uint8 {
+	return uint8(int(a) * int(b) / 255)
+}
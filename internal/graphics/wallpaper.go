@@ -0,0 +1,71 @@
+package graphics
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Wallpaper is a background image scaled and cropped to cover the whole grid.
+// Renderers draw it as-is, without the square standardization and shape mask icons get.
+type Wallpaper struct {
+	*image.RGBA
+}
+
+// NewWallpaper scales src to cover width x height pixels, cropping the overflow evenly on both sides.
+func NewWallpaper(src image.Image, width, height int) *Wallpaper {
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW <= 0 || srcH <= 0 {
+		return nil
+	}
+
+	// Scale by the larger factor so the image covers both dimensions
+	scale := float64(width) / float64(srcW)
+	if s := float64(height) / float64(srcH); s > scale {
+		scale = s
+	}
+	scaledW := max(int(float64(srcW)*scale+0.5), width)
+	scaledH := max(int(float64(srcH)*scale+0.5), height)
+
+	var scaled image.Image
+	if svg, ok := src.(*SVGIcon); ok {
+		scaled = svg.Rasterize(scaledW, scaledH)
+	} else {
+		scaled = ScaleImage(src, scaledW, scaledH)
+	}
+
+	// Center crop to the target size
+	offX := (scaledW - width) / 2
+	offY := (scaledH - height) / 2
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), scaled, scaled.Bounds().Min.Add(image.Pt(offX, offY)), draw.Src)
+	return &Wallpaper{dst}
+}
+
+// Backdrop returns the wallpaper pixels under r, in grid pixel coordinates.
+// Icons are composited over their backdrop so they blend into the wallpaper.
+func (w *Wallpaper) Backdrop(r image.Rectangle) image.Image {
+	return w.SubImage(r)
+}
+
+// Crop returns the wallpaper pixels under r, in grid pixel coordinates, as a wallpaper
+// of their own for redrawing part of the background.
+func (w *Wallpaper) Crop(r image.Rectangle) *Wallpaper {
+	r = r.Intersect(w.Bounds())
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), w, r.Min, draw.Src)
+	return &Wallpaper{dst}
+}
+
+// composeOver draws img over backdrop, aligning both at their top-left corners.
+func composeOver(img, backdrop image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), backdrop, backdrop.Bounds().Min, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,56 @@
+package sys
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// notificationListTimeout bounds a termux-notification-list call, which goes through the Termux:API app.
+const notificationListTimeout = 10 * time.Second
+
+// Notification is one entry of termux-notification-list output.
+type Notification struct {
+	ID          int    `json:"id"`
+	Key         string `json:"key"`
+	Group       string `json:"group"`
+	PackageName string `json:"packageName"`
+	Title       string `json:"title"`
+	Content     string `json:"content"`
+}
+
+// ListNotifications returns the active notifications via termux-notification-list.
+// Requires the Termux:API app and notification access for it.
+func ListNotifications() ([]Notification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), notificationListTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "termux-notification-list").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	var notifications []Notification
+	if err := json.Unmarshal(output, &notifications); err != nil {
+		return nil, fmt.Errorf("failed to parse notification list: %w", err)
+	}
+	return notifications, nil
+}
+
+// NotificationCounts returns the number of active notifications per package.
+func NotificationCounts() (map[string]int, error) {
+	notifications, err := ListNotifications()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, n := range notifications {
+		if n.PackageName != "" {
+			counts[n.PackageName]++
+		}
+	}
+	return counts, nil
+}
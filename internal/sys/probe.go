@@ -0,0 +1,195 @@
+package sys
+
+import (
+	"image/color"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"golang.org/x/sys/unix"
+)
+
+// DefaultProbeTimeout is how long to wait for the terminal to answer capability queries.
+const DefaultProbeTimeout = 300 * time.Millisecond
+
+// Terminal capability queries. DA1 is sent last: every terminal answers it and
+// replies arrive in order, so its answer marks the end of the probe.
+const (
+	queryKitty        = "\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\" // kitty graphics support
+	queryColorRegs    = "\x1b[?1;1;0S"                               // XTSMGRAPHICS: read color registers
+	querySixelGeom    = "\x1b[?2;1;0S"                               // XTSMGRAPHICS: read max sixel geometry
+	queryTextAreaPx   = "\x1b[14t"                                   // Text area size in pixels
+	queryCellPx       = "\x1b[16t"                                   // Cell size in pixels
+	queryBackground   = "\x1b]11;?\x07"                              // OSC 11: default background color
+	queryDeviceAttrs1 = "\x1b[c"                                     // DA1: primary device attributes
+)
+
+var (
+	da1Re       = regexp.MustCompile(`\x1b\[\?([0-9;]*)c`)
+	xtsmRe      = regexp.MustCompile(`\x1b\[\?([0-9]+);([0-9]+);([0-9;]*)S`)
+	textAreaRe  = regexp.MustCompile(`\x1b\[4;([0-9]+);([0-9]+)t`)
+	cellSizeRe  = regexp.MustCompile(`\x1b\[6;([0-9]+);([0-9]+)t`)
+	kittyRespRe = regexp.MustCompile(`\x1b_Gi=31;([^\x1b]*)\x1b\\`)
+	oscColorRe  = regexp.MustCompile(`\x1b\]11;rgba?:([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})`)
+)
+
+// TerminalCaps holds the capabilities reported by the terminal at startup.
+type TerminalCaps struct {
+	Responded      bool        // Terminal answered DA1 (so the other answers are trustworthy)
+	Sixel          bool        // DA1 lists Sixel graphics (attribute 4)
+	Kitty          bool        // Terminal acknowledged a kitty graphics query
+	ColorRegisters int         // Sixel color registers from XTSMGRAPHICS (0 if unknown)
+	MaxSixelWidth  int         // Maximum sixel width in pixels from XTSMGRAPHICS (0 if unknown)
+	MaxSixelHeight int         // Maximum sixel height in pixels from XTSMGRAPHICS (0 if unknown)
+	TextAreaPx     CellDim     // Text area size in pixels from CSI 14t (0 if unknown)
+	CellPx         CellDim     // Cell size in pixels from CSI 16t, or derived from CSI 14t (0 if unknown)
+	Background     color.Color // Default background color from OSC 11 (nil if unknown)
+}
+
+// LightBackground reports whether the terminal answered OSC 11 with a light color.
+// Unknown backgrounds count as dark, the common terminal default.
+func (c TerminalCaps) LightBackground() bool {
+	if c.Background == nil {
+		return false
+	}
+	r, g, b, _ := c.Background.RGBA()
+	// Rec. 709 relative luminance on 16-bit channels
+	return 0.2126*float64(r)+0.7152*float64(g)+0.0722*float64(b) > 0.5*0xffff
+}
+
+// ProbeTerminal queries the terminal for graphics capabilities and pixel geometry.
+// It must be called before the TUI takes over stdin. Terminals that don't answer
+// within the timeout yield zero-valued caps.
+func ProbeTerminal(timeout time.Duration) TerminalCaps {
+	var caps TerminalCaps
+
+	inFd := os.Stdin.Fd()
+	if !term.IsTerminal(inFd) || !term.IsTerminal(os.Stdout.Fd()) {
+		return caps
+	}
+
+	state, err := term.MakeRaw(inFd)
+	if err != nil {
+		return caps
+	}
+	defer term.Restore(inFd, state)
+
+	query := queryKitty + queryColorRegs + querySixelGeom + queryTextAreaPx + queryCellPx +
+		queryBackground + queryDeviceAttrs1
+	if _, err := os.Stdout.WriteString(query); err != nil {
+		return caps
+	}
+
+	response := readUntilDA1(int(inFd), timeout)
+	parseProbeResponse(response, &caps)
+
+	// Derive cell size from the text area if CSI 16t wasn't answered
+	if caps.CellPx.Width == 0 && caps.TextAreaPx.Width > 0 {
+		if cols, rows, err := term.GetSize(os.Stdout.Fd()); err == nil && cols > 0 && rows > 0 {
+			caps.CellPx = CellDim{
+				Width:  caps.TextAreaPx.Width / cols,
+				Height: caps.TextAreaPx.Height / rows,
+			}
+		}
+	}
+
+	return caps
+}
+
+// readUntilDA1 reads terminal responses until a DA1 answer arrives or the timeout expires.
+// Uses poll so no blocked read is left behind to steal input from the TUI.
+func readUntilDA1(fd int, timeout time.Duration) string {
+	var resp strings.Builder
+	buf := make([]byte, 256)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(remaining.Milliseconds())+1)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil || n == 0 {
+			break
+		}
+
+		n, err = unix.Read(fd, buf)
+		if err != nil || n <= 0 {
+			break
+		}
+		resp.Write(buf[:n])
+
+		if da1Re.MatchString(resp.String()) {
+			break
+		}
+	}
+
+	return resp.String()
+}
+
+// parseProbeResponse extracts capabilities from the raw terminal response.
+func parseProbeResponse(resp string, caps *TerminalCaps) {
+	if m := da1Re.FindStringSubmatch(resp); m != nil {
+		caps.Responded = true
+		for _, attr := range strings.Split(m[1], ";") {
+			if attr == "4" {
+				caps.Sixel = true
+			}
+		}
+	}
+
+	for _, m := range xtsmRe.FindAllStringSubmatch(resp, -1) {
+		// Status 0 means success; values follow
+		if m[2] != "0" {
+			continue
+		}
+		values := strings.Split(m[3], ";")
+		switch m[1] {
+		case "1":
+			caps.ColorRegisters = atoi(values[0])
+		case "2":
+			if len(values) >= 2 {
+				caps.MaxSixelWidth = atoi(values[0])
+				caps.MaxSixelHeight = atoi(values[1])
+			}
+		}
+	}
+
+	// CSI 14t/16t answers are height;width
+	if m := textAreaRe.FindStringSubmatch(resp); m != nil {
+		caps.TextAreaPx = CellDim{Width: atoi(m[2]), Height: atoi(m[1])}
+	}
+	if m := cellSizeRe.FindStringSubmatch(resp); m != nil {
+		caps.CellPx = CellDim{Width: atoi(m[2]), Height: atoi(m[1])}
+	}
+
+	if m := kittyRespRe.FindStringSubmatch(resp); m != nil && m[1] == "OK" {
+		caps.Kitty = true
+	}
+
+	// OSC 11 answers are rgb:RRRR/GGGG/BBBB with 1-4 hex digits per channel
+	if m := oscColorRe.FindStringSubmatch(resp); m != nil {
+		caps.Background = color.RGBA{R: oscChannel(m[1]), G: oscChannel(m[2]), B: oscChannel(m[3]), A: 255}
+	}
+}
+
+// oscChannel scales an X11 color channel of 1-4 hex digits to 8 bits.
+func oscChannel(hex string) uint8 {
+	v, _ := strconv.ParseUint(hex, 16, 32)
+	full := uint64(1)<<(4*len(hex)) - 1
+	return uint8(v * 255 / full)
+}
+
+// atoi parses an integer, returning 0 on error.
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
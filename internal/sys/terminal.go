@@ -23,6 +23,13 @@ type TerminalGeometry struct {
 
 // GetTerminalGeometry queries the terminal for its dimensions using ioctl.
 func GetTerminalGeometry() (TerminalGeometry, error) {
+	return GetTerminalGeometryWithCaps(TerminalCaps{})
+}
+
+// GetTerminalGeometryWithCaps queries the terminal for its dimensions using ioctl.
+// When the kernel doesn't know the pixel size (ws_xpixel/ws_ypixel are 0), the
+// cell size probed from the terminal is used before falling back to 10x20 px.
+func GetTerminalGeometryWithCaps(caps TerminalCaps) (TerminalGeometry, error) {
 	fd := int(os.Stdout.Fd())
 	ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
 	if err != nil {
@@ -39,12 +46,16 @@ func GetTerminalGeometry() (TerminalGeometry, error) {
 	// Calculate cell dimensions
 	if geom.XPixel > 0 && geom.Cols > 0 {
 		geom.CellDim.Width = geom.XPixel / geom.Cols
+	} else if caps.CellPx.Width > 0 {
+		geom.CellDim.Width = caps.CellPx.Width
 	} else {
 		geom.CellDim.Width = 10 // Fallback
 	}
 
 	if geom.YPixel > 0 && geom.Rows > 0 {
 		geom.CellDim.Height = geom.YPixel / geom.Rows
+	} else if caps.CellPx.Height > 0 {
+		geom.CellDim.Height = caps.CellPx.Height
 	} else {
 		geom.CellDim.Height = 20 // Fallback
 	}